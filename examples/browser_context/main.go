@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+
+	"github.com/falmar/gopilot/pkg/gopilot"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+	defer cancel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+
+	cfg := gopilot.NewBrowserConfig()
+	b := gopilot.NewBrowser(cfg, logger)
+
+	err := b.Open(ctx, &gopilot.BrowserOpenInput{})
+	if err != nil {
+		logger.Error("unable to open browser", "error", err)
+		return
+	}
+	defer b.Close(ctx)
+
+	// two sessions sharing the same browser process but no cookies
+	for _, user := range []string{"alice", "bob"} {
+		cOut, err := b.NewContext(ctx, &gopilot.BrowserNewContextInput{})
+		if err != nil {
+			logger.Error("unable to create context", "error", err)
+			return
+		}
+		bc := cOut.Context
+		defer bc.Close(ctx)
+
+		_, err = bc.SetCookies(ctx, &gopilot.SetCookiesInput{
+			Cookies: []gopilot.PageCookie{
+				{Domain: "example.com", Name: "user", Value: user, Path: "/"},
+			},
+		})
+		if err != nil {
+			logger.Error("unable to set cookies", "error", err)
+			return
+		}
+
+		pOut, err := bc.NewPage(ctx, &gopilot.BrowserNewPageInput{})
+		if err != nil {
+			logger.Error("unable to open page", "error", err)
+			return
+		}
+
+		_, err = pOut.Page.Navigate(ctx, &gopilot.PageNavigateInput{
+			URL:       "https://example.com",
+			WaitUntil: gopilot.WaitLoad,
+		})
+		if err != nil {
+			logger.Error("unable to navigate", "error", err)
+			return
+		}
+
+		gcOut, err := pOut.Page.GetCookies(ctx, &gopilot.GetCookiesInput{})
+		if err != nil {
+			logger.Error("unable to get cookies", "error", err)
+			return
+		}
+		for _, c := range gcOut.Cookies {
+			logger.Info("cookie found", "context", bc.GetID(), "name", c.Name, "value", c.Value)
+		}
+	}
+}
@@ -38,15 +38,13 @@ func main() {
 	time.Sleep(time.Second * 2)
 
 	if _, err := page.Navigate(ctx, &gopilot.PageNavigateInput{
-		URL:                "https://cps-check.com/mouse-buttons-test",
-		WaitDomContentLoad: true,
+		URL:       "https://cps-check.com/mouse-buttons-test",
+		WaitUntil: gopilot.WaitLoad,
 	}); err != nil {
 		logger.Error("unable to navigate", "error", err)
 		return
 	}
 
-	time.Sleep(time.Second * 2)
-
 	// or use page.QuerySelector
 	out, err := page.Search(ctx, &gopilot.PageSearchInput{
 		Selector: "#mouse-container",
@@ -60,13 +60,12 @@ func main() {
 	}
 
 	if _, err := page.Navigate(ctx, &gopilot.PageNavigateInput{
-		URL:                "https://www.google.com",
-		WaitDomContentLoad: true,
+		URL:       "https://www.google.com",
+		WaitUntil: gopilot.WaitLoad,
 	}); err != nil {
 		logger.Error("unable to navigate", "error", err)
 		return
 	}
-	time.Sleep(time.Second * 2)
 
 	// GET COOKIES
 	gcOut, err := page.GetCookies(ctx, &gopilot.GetCookiesInput{})
@@ -88,7 +87,7 @@ func main() {
 	}
 
 	// reload to see accept cookies popup
-	_, err = page.Reload(ctx, &gopilot.PageReloadInput{WaitDomContentLoad: true})
+	_, err = page.Reload(ctx, &gopilot.PageReloadInput{WaitUntil: gopilot.WaitLoad})
 	if err != nil {
 		logger.Error("unable to reload page", "error", err)
 		return
@@ -40,15 +40,13 @@ func main() {
 	time.Sleep(time.Second * 2)
 
 	if _, err := page.Navigate(ctx, &gopilot.PageNavigateInput{
-		URL:                "https://www.google.com",
-		WaitDomContentLoad: true,
+		URL:       "https://www.google.com",
+		WaitUntil: gopilot.WaitLoad,
 	}); err != nil {
 		logger.Error("unable to navigate", "error", err)
 		return
 	}
 
-	time.Sleep(time.Second * 2)
-
 	out, err := page.Evaluate(ctx, &gopilot.PageEvaluateInput{
 		ReturnValue:  true,
 		AwaitPromise: false,
@@ -45,8 +45,8 @@ func main() {
 	defer xMonitor.Stop(ctx)
 
 	if _, err := page.Navigate(ctx, &gopilot.PageNavigateInput{
-		URL:                "https://www.google.com",
-		WaitDomContentLoad: true,
+		URL:       "https://www.google.com",
+		WaitUntil: gopilot.WaitLoad,
 	}); err != nil {
 		logger.Error("unable to navigate", "error", err)
 		return
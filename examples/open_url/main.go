@@ -5,7 +5,6 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
-	"time"
 
 	"github.com/falmar/gopilot/pkg/gopilot"
 )
@@ -37,15 +36,13 @@ func main() {
 	defer page.Close(ctx)
 
 	_, err = page.Navigate(ctx, &gopilot.PageNavigateInput{
-		URL:                "https://www.google.com",
-		WaitDomContentLoad: true,
+		URL:       "https://www.google.com",
+		WaitUntil: gopilot.WaitLoad,
 	})
 	if err != nil {
 		logger.Error("unable to navigate", "error", err)
 		return
 	}
 
-	time.Sleep(2 * time.Second)
-
 	// do some magic ...
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+
+	"github.com/falmar/gopilot/pkg/gopilot"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+	defer cancel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+
+	cfg := gopilot.NewBrowserConfig()
+	cfg.RemoteURL = "127.0.0.1:9222"
+	b := gopilot.NewBrowser(cfg, logger)
+
+	err := b.Open(ctx, &gopilot.BrowserOpenInput{})
+	if err != nil {
+		logger.Error("unable to connect to browser", "error", err)
+		return
+	}
+	// detaches only, the remote browser keeps running
+	defer b.Close(ctx)
+
+	pOut, err := b.GetPages(ctx, &gopilot.BrowserGetPagesInput{})
+	if err != nil {
+		logger.Error("unable to get pages", "error", err)
+		return
+	}
+
+	for _, p := range pOut.Pages {
+		logger.Info("page found", "target_id", p.GetTargetID())
+	}
+}
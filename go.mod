@@ -2,6 +2,9 @@ module github.com/falmar/gopilot
 
 go 1.24.0
 
-require github.com/mafredri/cdp v0.35.0
+require (
+	github.com/mafredri/cdp v0.35.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require github.com/gorilla/websocket v1.5.3 // indirect
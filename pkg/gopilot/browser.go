@@ -1,11 +1,11 @@
 package gopilot
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/url"
 	"os"
 	"os/exec"
@@ -13,7 +13,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/mafredri/cdp"
 	"github.com/mafredri/cdp/devtool"
+	"github.com/mafredri/cdp/rpcc"
 )
 
 // Browser defines a contract for browser operations.
@@ -30,124 +32,399 @@ type Browser interface {
 	// or an error if the page cannot be created.
 	NewPage(ctx context.Context, in *BrowserNewPageInput) (*BrowserNewPageOutput, error)
 
-	// GetPages retrieves all active pages in the current browser session.
+	// GetPages retrieves all active pages in the current browser session, except
+	// the pages of browser contexts, which are listed by BrowserContext.GetPages.
 	// Requires a context and BrowserGetPagesInput for the request.
 	// Returns a BrowserGetPagesOutput with a list of pages or an error
 	// if retrieving the pages fails.
 	GetPages(ctx context.Context, in *BrowserGetPagesInput) (*BrowserGetPagesOutput, error)
 
+	// NewContext creates an isolated browser context (incognito-style session).
+	// Pages of different contexts share the browser process but no cookies or storage.
+	// Returns a BrowserNewContextOutput containing the context or an error.
+	NewContext(ctx context.Context, in *BrowserNewContextInput) (*BrowserNewContextOutput, error)
+
+	// OnPage registers a callback for page lifecycle events: pages created
+	// (including popups and target=_blank tabs), destroyed or crashed.
+	// Returns a handle to remove the callback with RemovePageListener.
+	OnPage(ctx context.Context, cb PageEventCallback) (*PageEventHandle, error)
+
+	// RemovePageListener removes a page event callback.
+	RemovePageListener(handle *PageEventHandle)
+
+	// WaitForPage waits for a new page to be created, optionally running
+	// a trigger action once it is listening. Returns the ready page.
+	WaitForPage(ctx context.Context, in *BrowserWaitForPageInput) (*BrowserWaitForPageOutput, error)
+
 	// Close shuts down the browser instance and cleans up any resources.
 	// It takes a context and returns an error if the browser fails to close.
+	// When attached to a remote browser it only detaches, leaving the process running.
+	// A launched browser gets SIGTERM and is killed, along with its children,
+	// once BrowserConfig.CloseGracePeriod elapses or ctx is done.
 	Close(ctx context.Context) error
 
 	// GetDevToolClient retrieves the DevTools client associated with the browser.
 	// This client allows for advanced interactions with the browser's DevTools protocol,
 	// enabling custom actions and low-level debugging or profiling features.
 	GetDevToolClient() *devtool.DevTools
+
+	// EnableDownloads allows downloads in the default browser context, saving
+	// them into a directory and emitting progress events.
+	EnableDownloads(ctx context.Context, in *BrowserEnableDownloadsInput) (*BrowserEnableDownloadsOutput, error)
+
+	// OnDownload registers a callback for download progress and completion events.
+	// Returns a handle to remove the callback with RemoveDownloadListener.
+	OnDownload(ctx context.Context, cb DownloadCallback) *DownloadHandle
+
+	// RemoveDownloadListener removes a download callback.
+	RemoveDownloadListener(handle *DownloadHandle)
+
+	// WaitForDownload waits for a download to begin and complete, optionally
+	// running a trigger action once it is listening. Returns the saved file.
+	WaitForDownload(ctx context.Context, in *BrowserWaitForDownloadInput) (*BrowserWaitForDownloadOutput, error)
+
+	// SetPermissions grants, denies or resets to prompt permissions such as
+	// geolocation or notifications for an origin, or all origins when empty.
+	SetPermissions(ctx context.Context, in *BrowserSetPermissionsInput) (*BrowserSetPermissionsOutput, error)
+
+	// ResetPermissions clears every permission override set with SetPermissions.
+	ResetPermissions(ctx context.Context, in *BrowserResetPermissionsInput) (*BrowserResetPermissionsOutput, error)
+
+	// Version retrieves the product, protocol version, user agent and V8
+	// version of the running browser. Returns an error if the browser is not open.
+	Version(ctx context.Context) (*BrowserVersion, error)
+
+	// Output returns the most recent lines written by the browser process to
+	// stdout and stderr, oldest first, with the Chrome log prefix parsed.
+	Output() []OutputLine
+
+	// GetWindowBounds returns the position, size and state of a window, its
+	// id is reported by Page.GetWindowBounds.
+	GetWindowBounds(ctx context.Context, windowID int) (*WindowBounds, error)
+
+	// SetWindowBounds moves and resizes a window, restoring it to the normal state first when needed.
+	SetWindowBounds(ctx context.Context, windowID int, in *WindowBounds) error
+
+	// SetWindowState maximizes, minimizes, restores or makes a window fullscreen.
+	SetWindowState(ctx context.Context, windowID int, state WindowState) error
+
+	// GetExtensions lists the loaded extensions that have a running service
+	// worker or background page, to evaluate scripts in them or open their pages.
+	GetExtensions(ctx context.Context, in *BrowserGetExtensionsInput) (*BrowserGetExtensionsOutput, error)
+
+	// Done returns a channel that is closed once the browser is no longer usable,
+	// either because Close was called or the process exited and was not restarted.
+	Done() <-chan struct{}
+
+	// Err returns the reason Done was closed, ErrBrowserClosed after Close or an
+	// error matching ErrBrowserExited after a crash. It returns nil while running.
+	Err() error
 }
 
 type browser struct {
 	config   *BrowserConfig
 	logger   *slog.Logger
 	instance *exec.Cmd
+	exited   chan struct{} // closed once instance exits
+	exitErr  error         // instance exit error, set before exited is closed
 	datadir  string
+	cleanup  bool
+	display  *virtualDisplay
+	remote   bool
 	mux      sync.RWMutex
 	devtool  *devtool.DevTools
 	pages    []Page
-	waitChan chan error
+	contexts []*browserContext
+
+	// process output, see recordOutput
+	output    *ring[OutputLine]
+	outputMux sync.Mutex // serializes writes to BrowserConfig.Output
+
+	// browser target connection, see browserClient
+	connMux sync.Mutex
+	conn    *rpcc.Conn
+	flat    *flatCodec
+	client  *cdp.Client
+
+	// page per target id, see attachOnce
+	attachMux sync.Mutex
+	attached  map[string]*pageAttach
+
+	// target discovery, see watchTargets
+	watchMux      sync.Mutex
+	watchCancel   context.CancelFunc
+	pageListeners map[*PageEventHandle]PageEventCallback
+
+	// downloads, see watchDownloads
+	downloadCancel    context.CancelFunc
+	downloadDir       string
+	downloadDirs      []string
+	downloadListeners map[*DownloadHandle]DownloadCallback
+
+	// supervision state
+	procMux       sync.Mutex // serializes restarts with Close
+	execPath      string
+	launchTimeout time.Duration
+	closing       bool
+	restarts      int
+	done          chan struct{}
+	err           error
 }
 
 // NewBrowser creates a new browser instance with the given configuration and logger.
 func NewBrowser(cfg *BrowserConfig, logger *slog.Logger) Browser {
 	return &browser{
-		config:   cfg,
-		logger:   logger,
-		pages:    make([]Page, 0),
-		waitChan: make(chan error),
+		config: cfg,
+		logger: logger,
+		pages:  make([]Page, 0),
+		done:   make(chan struct{}),
+
+		attached:          map[string]*pageAttach{},
+		pageListeners:     map[*PageEventHandle]PageEventCallback{},
+		downloadListeners: map[*DownloadHandle]DownloadCallback{},
 	}
 }
 
 // BrowserOpenInput contains parameters required to open a browser.
-type BrowserOpenInput struct{}
+type BrowserOpenInput struct {
+	// UserDataDir is an existing profile directory to launch the browser with,
+	// it is created when missing and never removed on Close.
+	UserDataDir string
+
+	// ProfileTemplate is a profile directory cloned into a temporary data dir,
+	// the template itself is left untouched. Cannot be combined with UserDataDir.
+	ProfileTemplate string
+
+	// KeepUserDataDir prevents the temporary data dir from being removed on Close.
+	KeepUserDataDir bool
+}
 
 // Open initializes and starts the browser process.
+// When BrowserConfig.RemoteURL is set it attaches to that DevTools endpoint
+// instead of launching a new process.
 func (b *browser) Open(ctx context.Context, in *BrowserOpenInput) error {
-	tempDir, err := os.MkdirTemp("", "gopilot")
+	// reset supervision state of a previous session
+	b.mux.Lock()
+	if b.err != nil {
+		b.done = make(chan struct{})
+		b.err = nil
+	}
+	b.closing = false
+	b.remote = false
+	b.restarts = 0
+	b.downloadDir = ""
+	b.downloadDirs = nil
+	b.mux.Unlock()
+
+	if b.config.RemoteURL != "" {
+		return b.connect(ctx)
+	}
+
+	return b.launch(ctx, in)
+}
+
+// connect attaches to an already running browser through its DevTools endpoint.
+func (b *browser) connect(ctx context.Context) error {
+	endpoint, err := devtoolEndpoint(b.config.RemoteURL)
 	if err != nil {
 		return err
 	}
-	b.datadir = tempDir
-	b.logger.Debug("created data dir", "path", b.datadir)
+	if endpoint, err = resolveDevtoolHost(ctx, endpoint); err != nil {
+		return fmt.Errorf("unable to resolve remote devtool %s: %w", b.config.RemoteURL, err)
+	}
 
-	b.instance = exec.Command(b.config.Path)
-	b.instance.Env = b.config.Envs
-	b.instance.Args = append(
-		b.config.Args,
-		fmt.Sprintf("--user-data-dir=%s", tempDir),
-	)
+	b.logger.Debug("connecting to remote devtool", "url", endpoint)
+	dt := devtool.New(endpoint)
 
-	// TODO: check if debug port is already in use (when unset)
-	// in order to use next one incrementally
-	if b.config.DebugPort != "" {
-		b.instance.Args = append(
-			b.instance.Args,
-			fmt.Sprintf("--remote-debugging-port=%s", b.config.DebugPort),
-		)
+	// make sure the endpoint is reachable before handing it out
+	if _, err = dt.Version(ctx); err != nil {
+		return fmt.Errorf("unable to reach remote devtool %s: %w", endpoint, err)
 	}
 
-	// Handle stderr to capture DevTools URL
-	dtChan := make(chan string)
-	stderr, err := b.instance.StderrPipe()
+	b.devtool = dt
+	b.remote = true
+
+	return nil
+}
+
+// launch starts a new browser process and waits for its DevTools endpoint,
+// retrying up to BrowserConfig.LaunchRetries times.
+func (b *browser) launch(ctx context.Context, in *BrowserOpenInput) error {
+	if in == nil {
+		in = &BrowserOpenInput{}
+	}
+
+	execPath, err := resolveExecutable(b.config.Path)
 	if err != nil {
+		return &LaunchError{Err: ErrExecutableNotFound, Cause: err}
+	}
+
+	if err = b.config.validateExtensions(execPath); err != nil {
 		return err
 	}
-	defer stderr.Close()
 
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if strings.Contains(line, "DevTools listening on") {
-				dtChan <- line
-			}
-			b.logger.Debug("chromesdterr", "msg", line)
+	timeout := b.config.LaunchTimeout
+	if timeout <= 0 {
+		timeout = defaultLaunchTimeout
+	}
+
+	dataDir, cleanup, err := prepareDataDir(in)
+	if err != nil {
+		return err
+	}
+	b.datadir = dataDir
+	b.cleanup = cleanup
+	b.logger.Debug("using data dir", "path", b.datadir, "cleanup", b.cleanup)
+
+	bufferSize := b.config.OutputBufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultOutputBufferSize
+	}
+	b.mux.Lock()
+	b.output = newRing[OutputLine](bufferSize)
+	b.mux.Unlock()
+
+	if err = b.startDisplay(ctx, timeout); err != nil {
+		b.removeDataDir()
+		return err
+	}
+
+	b.execPath = execPath
+	b.launchTimeout = timeout
+
+	var launchErr *LaunchError
+	for attempt := 1; attempt <= b.config.LaunchRetries+1; attempt++ {
+		err = b.start(ctx, execPath, timeout)
+		if err == nil {
+			go b.supervise(b.exited)
+			return nil
 		}
-	}()
 
-	err = b.instance.Start()
+		// only launch failures are worth retrying
+		if !errors.As(err, &launchErr) {
+			break
+		}
+		launchErr.Attempts = attempt
+		b.logger.Warn("browser launch attempt failed", "attempt", attempt, "error", launchErr.Err, "cause", launchErr.Cause)
+	}
+
+	b.removeDataDir()
+	b.stopDisplay()
+
+	return err
+}
+
+// start executes the browser once and waits up to timeout for its DevTools port.
+// On failure the process is killed before returning.
+func (b *browser) start(ctx context.Context, execPath string, timeout time.Duration) error {
+	b.instance = exec.Command(execPath)
+	b.instance.Env = b.browserEnv()
+	setProcessGroup(b.instance)
+
+	flags := b.config.launchFlags()
+	flags.Set("user-data-dir", b.datadir)
+
+	// port "0" lets the browser pick a free port which is then read
+	// from the DevToolsActivePort file in the data dir
+	debugPort := b.config.DebugPort
+	if isAutoDebugPort(debugPort) {
+		debugPort = "0"
+	}
+	flags.Set("remote-debugging-port", debugPort)
+
+	if len(b.config.Extensions) > 0 {
+		exts := strings.Join(b.config.Extensions, ",")
+		flags.Set("load-extension", exts).
+			Set("disable-extensions-except", exts).
+			// branded Chrome ignores --load-extension unless this feature is disabled
+			DisableFeatures("DisableLoadExtensionCommandLineSwitch")
+	}
+
+	if proxy := b.config.Proxy; proxy != nil && proxy.Server != "" {
+		flags.Set("proxy-server", proxy.Server)
+		if len(proxy.Bypass) > 0 {
+			flags.Set("proxy-bypass-list", proxy.bypassList())
+		}
+	}
+
+	b.instance.Args = append([]string{execPath}, flags.Args()...)
+
+	if err := removeDevToolsActivePort(b.datadir); err != nil {
+		return err
+	}
+
+	// Capture the output, stderr also carries the DevTools URL as a fallback of DevToolsActivePort
+	dtChan := make(chan string, 1)
+	capture := &outputCapture{
+		browser:  b,
+		tail:     newRing[string](outputTailSize),
+		devtools: dtChan,
+	}
+	stdout := capture.writer("stdout")
+	stderr := capture.writer("stderr")
+	b.instance.Stdout = stdout
+	b.instance.Stderr = stderr
+	// children outliving the browser keep the pipes open, don't wait on them forever
+	b.instance.WaitDelay = time.Second
+
+	err := b.instance.Start()
 	if err != nil {
 		return err
 	}
 	b.logger.Debug("waiting for devtool url message")
 
+	cmd := b.instance
+	exited := make(chan struct{})
+	b.exited = exited
 	go func() {
-		b.waitChan <- b.instance.Wait()
+		b.exitErr = cmd.Wait()
+		stdout.flush()
+		stderr.flush()
+		close(exited)
 	}()
 
-	// Wait for the DevTools URL message or timeout
-	waitDuration := time.Second * 5
-	var devtoolsURLString string
+	watchCtx, cancelWatch := context.WithCancel(ctx)
+	defer cancelWatch()
+	portChan := watchDevToolsActivePort(watchCtx, b.datadir)
+
+	// kill the process and wait for it so the next attempt starts clean
+	abort := func() {
+		_ = killProcess(cmd)
+		<-exited
+	}
+
+	// Wait for the DevTools port or timeout
+	var port string
 	select {
-	case err := <-b.waitChan:
-		return fmt.Errorf("exec wait exited unexpectedly or too soon: %w", err)
-	case <-time.NewTimer(waitDuration).C:
-		return fmt.Errorf("duration %s exceeded waiting for devtool url", waitDuration)
+	case <-ctx.Done():
+		abort()
+		return ctx.Err()
+	case <-exited:
+		return &LaunchError{Err: ErrBrowserExited, Cause: b.exitErr, Output: capture.tail.Values()}
+	case <-time.NewTimer(timeout).C:
+		abort()
+		return &LaunchError{
+			Err:    ErrLaunchTimeout,
+			Cause:  fmt.Errorf("duration %s exceeded waiting for devtool url", timeout),
+			Output: capture.tail.Values(),
+		}
 
-	// successful case
+	// successful cases
+	case port = <-portChan:
+		b.logger.Debug("read devtools active port", "port", port)
 	case dtMessage := <-dtChan:
 		dtSplit := strings.Split(dtMessage, "DevTools listening on")
-		if len(dtSplit) < 2 {
-			return errors.New("unable to obtain dev tool url")
+		devtoolURL, err := url.Parse(strings.TrimSpace(dtSplit[1]))
+		if err != nil {
+			abort()
+			return err
 		}
-		devtoolsURLString = strings.TrimSpace(dtSplit[1])
-	}
-	devtoolURL, err := url.Parse(devtoolsURLString)
-	if err != nil {
-		return err
+		port = devtoolURL.Port()
 	}
 
+	devtoolsURLString := fmt.Sprintf("http://127.0.0.1:%s", port)
 	b.logger.Debug("creating devtool", "url", devtoolsURLString)
-	b.devtool = devtool.New(fmt.Sprintf("http://127.0.0.1:%s", devtoolURL.Port()))
+	b.devtool = devtool.New(devtoolsURLString)
 
 	return nil
 }
@@ -169,7 +446,9 @@ func (b *browser) NewPage(ctx context.Context, in *BrowserNewPageInput) (*Browse
 	var t *devtool.Target
 	var err error
 
-	if in.NewTab {
+	if b.config.FlattenSessions {
+		t, err = b.newPageTarget(ctx, in.NewTab)
+	} else if in.NewTab {
 		t, err = b.devtool.Create(ctx)
 	} else {
 		t, err = b.devtool.Get(ctx, devtool.Page)
@@ -179,14 +458,11 @@ func (b *browser) NewPage(ctx context.Context, in *BrowserNewPageInput) (*Browse
 		return nil, err
 	}
 
-	p, err := newPage(ctx, t, b.logger)
+	p, err := b.attachTarget(ctx, t, b.config.Proxy)
 	if err != nil {
 		return nil, err
 	}
-
-	b.mux.Lock()
-	b.pages = append(b.pages, p)
-	b.mux.Unlock()
+	b.addPage(p)
 
 	return &BrowserNewPageOutput{Page: p}, nil
 }
@@ -199,7 +475,7 @@ type BrowserGetPagesOutput struct {
 	Pages []Page
 }
 
-// GetPages retrieves the list of active pages in the browser.
+// GetPages retrieves the list of active pages in the default browser context.
 func (b *browser) GetPages(ctx context.Context, _ *BrowserGetPagesInput) (*BrowserGetPagesOutput, error) {
 	var pg []Page
 
@@ -214,18 +490,15 @@ func (b *browser) GetPages(ctx context.Context, _ *BrowserGetPagesInput) (*Brows
 		pg = append(pg, p)
 	}
 
-	// List available pages from devtool
-	targets, err := b.devtool.List(ctx)
+	// List available pages of the default context, the pages of
+	// browser contexts are listed by their own GetPages
+	targets, err := b.listDefaultPageTargets(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// Add new targets to the list
 	for _, t := range targets {
-		if t.Type != devtool.Page {
-			continue
-		}
-
 		var present bool
 		for _, p := range pg {
 			if t.ID == p.(*page).id {
@@ -235,7 +508,7 @@ func (b *browser) GetPages(ctx context.Context, _ *BrowserGetPagesInput) (*Brows
 		}
 
 		if !present {
-			p, err := newPage(ctx, t, b.logger)
+			p, err := b.attachTarget(ctx, t, b.config.Proxy)
 			if err != nil {
 				return nil, err
 			}
@@ -250,10 +523,67 @@ func (b *browser) GetPages(ctx context.Context, _ *BrowserGetPagesInput) (*Brows
 }
 
 // Close shuts down the browser and cleans up resources.
+// A remote browser is only detached from, its process and pages are left running.
+// Closing a browser that was never opened does nothing.
 func (b *browser) Close(ctx context.Context) error {
-	b.logger.Debug("closing pages", "len", len(b.pages))
+	// contexts, pages and the process share a single grace period, so a hung
+	// browser is killed once it elapses whichever step it hangs in
+	deadline := time.Now().Add(b.closeGracePeriod())
+	graceCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	b.closeContexts(graceCtx)
+	defer b.closeClient()
+
+	if b.remote {
+		defer b.finish(ErrBrowserClosed)
+		return b.detach()
+	}
+
+	// never launched, nothing to shut down
+	if b.instance == nil || b.instance.Process == nil {
+		return nil
+	}
+
+	b.procMux.Lock()
+	defer b.procMux.Unlock()
+
+	b.mux.Lock()
+	b.closing = true
+	b.mux.Unlock()
 
+	b.closePages(graceCtx)
+
+	b.devtool = nil
+	b.pages = nil
+	b.resetAttached()
+
+	defer b.finish(ErrBrowserClosed)
+
+	// the process may already be gone after a failed restart
+	select {
+	case <-b.exited:
+		b.removeDataDir()
+		b.stopDisplay()
+		return nil
+	default:
+	}
+
+	defer b.stopDisplay()
+	defer b.removeDataDir()
+
+	return b.terminate(ctx, deadline)
+}
+
+// closePages closes the open pages on a best-effort basis, the process is
+// terminated afterwards anyway. A hung renderer must not hold up Close, so
+// ctx is bounded by the close grace period.
+func (b *browser) closePages(ctx context.Context) {
 	b.mux.RLock()
+	defer b.mux.RUnlock()
+
+	b.logger.Debug("closing pages", "len", len(b.pages))
+
 	for _, p := range b.pages {
 		p := p.(*page)
 		if p.closed {
@@ -261,33 +591,146 @@ func (b *browser) Close(ctx context.Context) error {
 			continue
 		}
 		b.logger.Debug("closing page", "target_id", p.target.ID)
-		err := p.Close(ctx)
-		if err != nil && !errors.Is(err, context.Canceled) {
-			return err
+		if err := p.Close(ctx); err != nil {
+			b.logger.Debug("unable to close page", "target_id", p.target.ID, "error", err)
+		}
+	}
+}
+
+// closeGracePeriod returns BrowserConfig.CloseGracePeriod or its default.
+func (b *browser) closeGracePeriod() time.Duration {
+	if b.config.CloseGracePeriod <= 0 {
+		return defaultCloseGracePeriod
+	}
+	return b.config.CloseGracePeriod
+}
+
+// terminate sends SIGTERM to the browser process group and waits until the
+// grace period deadline (or until ctx is done) before killing it.
+// Leftover children are always killed once the main process has exited.
+func (b *browser) terminate(ctx context.Context, deadline time.Time) error {
+	grace := time.Until(deadline)
+
+	b.logger.Debug("terminating browser process", "pid", b.instance.Process.Pid, "grace", grace)
+	if err := terminateProcess(b.instance); err != nil {
+		b.logger.Warn("unable to terminate browser process", "error", err)
+	}
+
+	var err error
+	select {
+	case <-b.exited:
+	case <-time.NewTimer(grace).C:
+		b.logger.Warn("browser did not exit in time, killing it", "grace", grace)
+	case <-ctx.Done():
+		b.logger.Warn("close context done, killing browser", "error", ctx.Err())
+		err = ctx.Err()
+	}
+
+	if kErr := killProcess(b.instance); kErr != nil {
+		b.logger.Warn("unable to kill browser process group", "error", kErr)
+	}
+	<-b.exited
+
+	return err
+}
+
+// removeDataDir deletes the data dir unless it belongs to the user or should be kept.
+func (b *browser) removeDataDir() {
+	if !b.cleanup {
+		b.logger.Debug("keeping data dir", "path", b.datadir)
+		return
+	}
+	if _, err := os.Stat(b.datadir); !os.IsNotExist(err) {
+		err = os.RemoveAll(b.datadir)
+		if err != nil {
+			b.logger.Warn("removing data dir error", "path", b.datadir, "error", err)
+		} else {
+			b.logger.Debug("removed data dir", "path", b.datadir)
+		}
+	}
+}
+
+// detach releases the page connections of a remote browser without closing its targets.
+func (b *browser) detach() error {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.logger.Debug("detaching from remote browser", "pages", len(b.pages))
+
+	for _, p := range b.pages {
+		p := p.(*page)
+		if p.closed {
+			continue
+		}
+		if err := p.conn.Close(); err != nil {
+			b.logger.Debug("unable to close page connection", "target_id", p.id, "error", err)
 		}
 	}
-	b.mux.RUnlock()
 
 	b.devtool = nil
 	b.pages = nil
+	b.resetAttached()
 
-	err := b.instance.Process.Signal(os.Interrupt)
+	return nil
+}
+
+// devtoolEndpoint normalizes a DevTools address into the HTTP endpoint
+// expected by devtool.New. It accepts "host:port", "http(s)://host:port"
+// and browser websocket URLs such as "ws://host:port/devtools/browser/<id>".
+func devtoolEndpoint(raw string) (string, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid devtool url: %s", raw)
 	}
 
-	defer func() {
-		if _, err = os.Stat(b.datadir); !os.IsNotExist(err) {
-			err = os.RemoveAll(b.datadir)
-			if err != nil {
-				b.logger.Warn("removing data dir error", "path", b.datadir, "error", err)
-			} else {
-				b.logger.Debug("removed data dir", "path", b.datadir)
-			}
-		}
-	}()
+	switch u.Scheme {
+	case "http", "ws":
+		return fmt.Sprintf("http://%s", u.Host), nil
+	case "https", "wss":
+		return fmt.Sprintf("https://%s", u.Host), nil
+	default:
+		return "", fmt.Errorf("unsupported devtool url scheme: %s", u.Scheme)
+	}
+}
+
+// resolveDevtoolHost replaces the hostname of an http endpoint with its IP address.
+// Chrome rejects DevTools HTTP requests whose Host header is neither an IP address
+// nor localhost, e.g. "chrome:9222" for a browser in a sidecar container. The
+// websocket URLs it returns are then built from the IP address as well.
+// https endpoints are left untouched, their certificate is issued for the name.
+func resolveDevtoolHost(ctx context.Context, endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	host := u.Hostname()
+	if u.Scheme != "http" || net.ParseIP(host) != nil || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return endpoint, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return "", err
+	}
+
+	// LookupHost returns at least one address on success
+	if port := u.Port(); port != "" {
+		u.Host = net.JoinHostPort(addrs[0], port)
+	} else if strings.Contains(addrs[0], ":") {
+		u.Host = "[" + addrs[0] + "]"
+	} else {
+		u.Host = addrs[0]
+	}
 
-	return <-b.waitChan
+	return u.String(), nil
 }
 
 // GetDevToolClient retrieves the DevTools client associated with the browser.
@@ -0,0 +1,275 @@
+package gopilot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/mafredri/cdp"
+	"github.com/mafredri/cdp/devtool"
+	"github.com/mafredri/cdp/protocol/target"
+	"github.com/mafredri/cdp/rpcc"
+)
+
+// browserClient returns the CDP client connected to the browser target,
+// dialing its websocket on first use.
+func (b *browser) browserClient(ctx context.Context) (*cdp.Client, error) {
+	b.connMux.Lock()
+	defer b.connMux.Unlock()
+
+	if b.client != nil {
+		return b.client, nil
+	}
+
+	dt := b.GetDevToolClient()
+	if dt == nil {
+		return nil, ErrBrowserClosed
+	}
+
+	v, err := dt.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b.logger.Debug("creating browser rpc conn", "url", v.WebSocketDebuggerURL)
+	conn, flat, err := b.newBrowserConn(ctx, v.WebSocketDebuggerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	b.conn = conn
+	b.flat = flat
+	b.client = cdp.NewClient(conn)
+
+	return b.client, nil
+}
+
+// closeClient closes the browser target connection, if any.
+func (b *browser) closeClient() {
+	b.stopWatchingTargets()
+	b.stopWatchingDownloads()
+
+	b.connMux.Lock()
+	defer b.connMux.Unlock()
+
+	if b.conn == nil {
+		return
+	}
+	if err := b.conn.Close(); err != nil {
+		b.logger.Debug("unable to close browser rpc conn", "error", err)
+	}
+	b.conn = nil
+	b.flat = nil
+	b.client = nil
+}
+
+// listPageTargets returns the page targets of the browser. In flatten mode they
+// come from Target.getTargets, which includes targets without an HTTP listing.
+func (b *browser) listPageTargets(ctx context.Context) ([]*devtool.Target, error) {
+	if !b.config.FlattenSessions {
+		dt := b.GetDevToolClient()
+		if dt == nil {
+			return nil, ErrBrowserClosed
+		}
+
+		targets, err := dt.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var pages []*devtool.Target
+		for _, t := range targets {
+			if t.Type == devtool.Page {
+				pages = append(pages, t)
+			}
+		}
+		return pages, nil
+	}
+
+	client, err := b.browserClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rp, err := client.Target.GetTargets(ctx, target.NewGetTargetsArgs())
+	if err != nil {
+		return nil, err
+	}
+
+	var pages []*devtool.Target
+	for _, t := range rp.TargetInfos {
+		if t.Type != string(devtool.Page) {
+			continue
+		}
+		pages = append(pages, &devtool.Target{
+			ID:    string(t.TargetID),
+			Type:  devtool.Page,
+			Title: t.Title,
+			URL:   t.URL,
+		})
+	}
+	return pages, nil
+}
+
+// listDefaultPageTargets returns the page targets of the default browser context,
+// leaving out the pages of contexts created with Target.createBrowserContext.
+func (b *browser) listDefaultPageTargets(ctx context.Context) ([]*devtool.Target, error) {
+	targets, err := b.listPageTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := b.browserClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	contexts, err := client.Target.GetBrowserContexts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(contexts.BrowserContextIDs) == 0 {
+		return targets, nil
+	}
+
+	rp, err := client.Target.GetTargets(ctx, target.NewGetTargetsArgs())
+	if err != nil {
+		return nil, err
+	}
+
+	other := map[string]bool{}
+	for _, t := range rp.TargetInfos {
+		if t.BrowserContextID != nil && slices.Contains(contexts.BrowserContextIDs, *t.BrowserContextID) {
+			other[string(t.TargetID)] = true
+		}
+	}
+
+	return slices.DeleteFunc(targets, func(t *devtool.Target) bool { return other[t.ID] }), nil
+}
+
+// pageAttach is the attachment to a page target shared by every caller
+// asking for it, see attachOnce.
+type pageAttach struct {
+	done chan struct{} // closed once page or err is set
+	page Page
+	err  error
+}
+
+// attachOnce returns the Page of the target id, calling dial only when the
+// target is not attached yet. Callers racing for the same target, such as
+// NewPage and the target watcher for a new tab, get the same Page.
+func (b *browser) attachOnce(ctx context.Context, id string, dial func() (Page, error)) (Page, error) {
+	for {
+		b.attachMux.Lock()
+		a, ok := b.attached[id]
+		if !ok {
+			a = &pageAttach{done: make(chan struct{})}
+			b.attached[id] = a
+			b.attachMux.Unlock()
+
+			a.page, a.err = dial()
+			if a.err != nil {
+				b.forgetTarget(id, a)
+			}
+			close(a.done)
+
+			return a.page, a.err
+		}
+		b.attachMux.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-a.done:
+		}
+
+		if a.err == nil && !a.page.(*page).isClosed() {
+			return a.page, nil
+		}
+
+		// the other attempt failed or its page was closed since, start over
+		b.forgetTarget(id, a)
+	}
+}
+
+// forgetTarget drops the attachment of the target id, if a is still the current one.
+func (b *browser) forgetTarget(id string, a *pageAttach) {
+	b.attachMux.Lock()
+	defer b.attachMux.Unlock()
+
+	if b.attached[id] == a {
+		delete(b.attached, id)
+	}
+}
+
+// resetAttached forgets every attachment once the pages are released.
+func (b *browser) resetAttached() {
+	b.attachMux.Lock()
+	defer b.attachMux.Unlock()
+
+	b.attached = map[string]*pageAttach{}
+}
+
+// attachTarget returns the Page of the target, see attachOnce.
+// When proxy has credentials the page answers proxy auth challenges with them.
+func (b *browser) attachTarget(ctx context.Context, t *devtool.Target, proxy *ProxyConfig) (Page, error) {
+	return b.attachOnce(ctx, t.ID, func() (Page, error) {
+		return b.dialPage(ctx, t, proxy)
+	})
+}
+
+// attachTargetID returns the Page of the page target with the given id, see attachOnce.
+func (b *browser) attachTargetID(ctx context.Context, id string, proxy *ProxyConfig) (Page, error) {
+	return b.attachOnce(ctx, id, func() (Page, error) {
+		targets, err := b.listPageTargets(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range targets {
+			if t.ID == id {
+				return b.dialPage(ctx, t, proxy)
+			}
+		}
+
+		return nil, fmt.Errorf("target %s not found", id)
+	})
+}
+
+// dialPage creates a Page for the target, either dialing its own
+// websocket or, in flatten mode, a session over the browser connection.
+func (b *browser) dialPage(ctx context.Context, t *devtool.Target, proxy *ProxyConfig) (Page, error) {
+	var p Page
+	var err error
+
+	if b.config.FlattenSessions {
+		var conn *rpcc.Conn
+		conn, err = b.dialSession(ctx, target.ID(t.ID))
+		if err != nil {
+			return nil, err
+		}
+		p, err = newPageWithConn(ctx, t, conn, b.logger)
+	} else {
+		p, err = newPage(ctx, t, b.logger)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.(*page).setProxyAuth(ctx, proxy); err != nil {
+		_ = p.(*page).conn.Close()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// addPage tracks p in the browser pages unless it already is.
+func (b *browser) addPage(p Page) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if !slices.Contains(b.pages, p) {
+		b.pages = append(b.pages, p)
+	}
+}
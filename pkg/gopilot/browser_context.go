@@ -0,0 +1,340 @@
+package gopilot
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	cdpbrowser "github.com/mafredri/cdp/protocol/browser"
+	"github.com/mafredri/cdp/protocol/storage"
+	"github.com/mafredri/cdp/protocol/target"
+)
+
+// BrowserContext is an isolated browser session, similar to an incognito window.
+// Pages created within a context share its cookies and storage
+// but nothing with other contexts of the same browser.
+type BrowserContext interface {
+	// NewPage creates a new page within the context.
+	// Returns a BrowserNewPageOutput containing the page or an error if creation fails.
+	NewPage(ctx context.Context, in *BrowserNewPageInput) (*BrowserNewPageOutput, error)
+
+	// GetPages retrieves all active pages of the context.
+	// Returns a BrowserGetPagesOutput with the list of pages or an error.
+	GetPages(ctx context.Context, in *BrowserGetPagesInput) (*BrowserGetPagesOutput, error)
+
+	// GetCookies retrieves all cookies of the context.
+	GetCookies(ctx context.Context, in *GetCookiesInput) (*GetCookiesOutput, error)
+
+	// SetCookies sets cookies in the context.
+	SetCookies(ctx context.Context, in *SetCookiesInput) (*SetCookiesOutput, error)
+
+	// ClearCookies clears all cookies of the context.
+	ClearCookies(ctx context.Context, in *ClearCookiesInput) (*ClearCookiesOutput, error)
+
+	// EnableDownloads allows downloads in the context, saving them into a directory.
+	// Progress is reported through the Browser download events.
+	EnableDownloads(ctx context.Context, in *BrowserEnableDownloadsInput) (*BrowserEnableDownloadsOutput, error)
+
+	// WaitForDownload waits for a download to begin and complete, see Browser.WaitForDownload.
+	WaitForDownload(ctx context.Context, in *BrowserWaitForDownloadInput) (*BrowserWaitForDownloadOutput, error)
+
+	// SetPermissions grants, denies or resets to prompt permissions of an origin in the context.
+	SetPermissions(ctx context.Context, in *BrowserSetPermissionsInput) (*BrowserSetPermissionsOutput, error)
+
+	// ResetPermissions clears every permission override of the context.
+	ResetPermissions(ctx context.Context, in *BrowserResetPermissionsInput) (*BrowserResetPermissionsOutput, error)
+
+	// GetID returns the browser context id.
+	GetID() string
+
+	// Close closes all the pages of the context and disposes it.
+	Close(ctx context.Context) error
+}
+
+type browserContext struct {
+	id      cdpbrowser.ContextID
+	browser *browser
+	proxy   *ProxyConfig
+	mux     sync.RWMutex
+	pages   []Page
+	closed  bool
+}
+
+// BrowserNewContextInput contains parameters for creating a new browser context.
+type BrowserNewContextInput struct {
+	// Proxy overrides the browser proxy for the pages of the context.
+	Proxy *ProxyConfig
+}
+
+// BrowserNewContextOutput contains the newly created browser context.
+type BrowserNewContextOutput struct {
+	Context BrowserContext
+}
+
+// NewContext creates a new isolated browser context.
+func (b *browser) NewContext(ctx context.Context, in *BrowserNewContextInput) (*BrowserNewContextOutput, error) {
+	client, err := b.browserClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	args := target.NewCreateBrowserContextArgs()
+	if in.Proxy != nil && in.Proxy.Server != "" {
+		args.SetProxyServer(in.Proxy.Server)
+		if len(in.Proxy.Bypass) > 0 {
+			args.SetProxyBypassList(in.Proxy.bypassList())
+		}
+	}
+
+	rp, err := client.Target.CreateBrowserContext(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	b.logger.Debug("created browser context", "context_id", rp.BrowserContextID)
+
+	bc := &browserContext{
+		id:      rp.BrowserContextID,
+		browser: b,
+		proxy:   in.Proxy,
+		pages:   make([]Page, 0),
+	}
+
+	b.mux.Lock()
+	b.contexts = append(b.contexts, bc)
+	b.mux.Unlock()
+
+	return &BrowserNewContextOutput{Context: bc}, nil
+}
+
+// GetID returns the browser context id.
+func (c *browserContext) GetID() string {
+	return string(c.id)
+}
+
+// EnableDownloads allows downloads in the browser context.
+func (c *browserContext) EnableDownloads(ctx context.Context, in *BrowserEnableDownloadsInput) (*BrowserEnableDownloadsOutput, error) {
+	dir, err := c.browser.setDownloadBehavior(ctx, in.Dir, &c.id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BrowserEnableDownloadsOutput{Dir: dir}, nil
+}
+
+// WaitForDownload waits for a download to begin and complete.
+// Download events are browser wide, use Match to tell apart concurrent downloads.
+func (c *browserContext) WaitForDownload(ctx context.Context, in *BrowserWaitForDownloadInput) (*BrowserWaitForDownloadOutput, error) {
+	return c.browser.WaitForDownload(ctx, in)
+}
+
+// proxyConfig returns the proxy of the context, inheriting the browser one when unset.
+func (c *browserContext) proxyConfig() *ProxyConfig {
+	if c.proxy != nil {
+		return c.proxy
+	}
+	return c.browser.config.Proxy
+}
+
+// NewPage creates a new tab within the browser context.
+func (c *browserContext) NewPage(ctx context.Context, _ *BrowserNewPageInput) (*BrowserNewPageOutput, error) {
+	client, err := c.browser.browserClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rp, err := client.Target.CreateTarget(ctx, target.NewCreateTargetArgs("about:blank").SetBrowserContextID(c.id))
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := c.browser.attachTargetID(ctx, string(rp.TargetID), c.proxyConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	c.addPage(p)
+
+	return &BrowserNewPageOutput{Page: p}, nil
+}
+
+// GetPages retrieves the pages that belong to the browser context.
+func (c *browserContext) GetPages(ctx context.Context, _ *BrowserGetPagesInput) (*BrowserGetPagesOutput, error) {
+	client, err := c.browser.browserClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rp, err := client.Target.GetTargets(ctx, target.NewGetTargetsArgs())
+	if err != nil {
+		return nil, err
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	var pg []Page
+	for _, p := range c.pages {
+		if p.(*page).closed {
+			continue
+		}
+		pg = append(pg, p)
+	}
+
+	for _, t := range rp.TargetInfos {
+		if t.Type != "page" || t.BrowserContextID == nil || *t.BrowserContextID != c.id {
+			continue
+		}
+
+		var present bool
+		for _, p := range pg {
+			if string(t.TargetID) == p.(*page).id {
+				present = true
+				break
+			}
+		}
+
+		if !present {
+			p, err := c.browser.attachTargetID(ctx, string(t.TargetID), c.proxyConfig())
+			if err != nil {
+				return nil, err
+			}
+			pg = append(pg, p)
+		}
+	}
+
+	c.pages = pg
+
+	return &BrowserGetPagesOutput{Pages: pg}, nil
+}
+
+// GetCookies retrieves all cookies of the browser context.
+func (c *browserContext) GetCookies(ctx context.Context, _ *GetCookiesInput) (*GetCookiesOutput, error) {
+	client, err := c.browser.browserClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rp, err := client.Storage.GetCookies(ctx, storage.NewGetCookiesArgs().SetBrowserContextID(c.id))
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetCookiesOutput{Cookies: toPageCookies(rp.Cookies)}, nil
+}
+
+// SetCookies sets the specified cookies in the browser context.
+func (c *browserContext) SetCookies(ctx context.Context, in *SetCookiesInput) (*SetCookiesOutput, error) {
+	client, err := c.browser.browserClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	args := storage.NewSetCookiesArgs(toCookieParams(in.Cookies)).SetBrowserContextID(c.id)
+	if err = client.Storage.SetCookies(ctx, args); err != nil {
+		return nil, err
+	}
+
+	return &SetCookiesOutput{}, nil
+}
+
+// ClearCookies clears all cookies of the browser context.
+func (c *browserContext) ClearCookies(ctx context.Context, _ *ClearCookiesInput) (*ClearCookiesOutput, error) {
+	client, err := c.browser.browserClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = client.Storage.ClearCookies(ctx, storage.NewClearCookiesArgs().SetBrowserContextID(c.id)); err != nil {
+		return nil, err
+	}
+
+	return &ClearCookiesOutput{}, nil
+}
+
+// Close releases the pages of the browser context and disposes it,
+// which closes every target that still belongs to it.
+func (c *browserContext) Close(ctx context.Context) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	for _, p := range c.pages {
+		p := p.(*page)
+		p.mux.Lock()
+		p.closed = true
+		p.mux.Unlock()
+		_ = p.conn.Close()
+	}
+	c.pages = nil
+
+	client, err := c.browser.browserClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = client.Target.DisposeBrowserContext(ctx, target.NewDisposeBrowserContextArgs(c.id))
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	c.closed = true
+	c.browser.removeContext(c)
+
+	c.browser.logger.Debug("disposed browser context", "context_id", c.id)
+
+	return nil
+}
+
+// contextFor returns the tracked browser context with the given id, if any.
+func (b *browser) contextFor(contextID string) *browserContext {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+
+	for _, c := range b.contexts {
+		if string(c.id) == contextID {
+			return c
+		}
+	}
+	return nil
+}
+
+// addPage tracks p in the context pages unless it already is.
+func (c *browserContext) addPage(p Page) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if !slices.Contains(c.pages, p) {
+		c.pages = append(c.pages, p)
+	}
+}
+
+// removeContext stops tracking a closed browser context.
+func (b *browser) removeContext(c *browserContext) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	for i, bc := range b.contexts {
+		if bc == c {
+			b.contexts = append(b.contexts[:i], b.contexts[i+1:]...)
+			return
+		}
+	}
+}
+
+// closeContexts disposes every browser context created through NewContext.
+// ctx is bounded by the close grace period, an unresponsive browser must not hold up Close.
+func (b *browser) closeContexts(ctx context.Context) {
+	b.mux.RLock()
+	contexts := make([]*browserContext, len(b.contexts))
+	copy(contexts, b.contexts)
+	b.mux.RUnlock()
+
+	for _, c := range contexts {
+		if err := c.Close(ctx); err != nil {
+			b.logger.Warn("unable to close browser context", "context_id", c.id, "error", err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package gopilot
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// VirtualDisplayConfig configures the Xvfb server started for a headful browser.
+type VirtualDisplayConfig struct {
+	// Path is the Xvfb executable, defaults to "Xvfb".
+	Path string
+
+	// Screen is the screen geometry and depth, defaults to "1920x1080x24".
+	Screen string
+
+	// Args contains additional command-line arguments for Xvfb.
+	Args []string
+}
+
+// virtualDisplay is a running Xvfb server.
+type virtualDisplay struct {
+	cmd    *exec.Cmd
+	name   string        // DISPLAY value, e.g. ":99"
+	exited chan struct{} // closed once cmd exits
+}
+
+// startVirtualDisplay starts Xvfb and waits up to timeout for it to report
+// the display number it picked through -displayfd.
+func startVirtualDisplay(ctx context.Context, cfg *VirtualDisplayConfig, timeout time.Duration) (*virtualDisplay, error) {
+	path := cfg.Path
+	if path == "" {
+		path = "Xvfb"
+	}
+	screen := cfg.Screen
+	if screen == "" {
+		screen = "1920x1080x24"
+	}
+
+	execPath, err := exec.LookPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	cmd := exec.Command(execPath)
+	setProcessGroup(cmd)
+	// fd 3 is the first of ExtraFiles, Xvfb writes the free display number it found there
+	cmd.Args = append([]string{execPath, "-displayfd", "3", "-screen", "0", screen, "-nolisten", "tcp"}, cfg.Args...)
+	cmd.ExtraFiles = []*os.File{w}
+
+	err = cmd.Start()
+	_ = w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &virtualDisplay{cmd: cmd, exited: make(chan struct{})}
+	go func() {
+		_ = cmd.Wait()
+		close(d.exited)
+	}()
+
+	numChan := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(r).ReadString('\n')
+		numChan <- strings.TrimSpace(line)
+	}()
+
+	abort := func() {
+		_ = killProcess(cmd)
+		<-d.exited
+	}
+
+	select {
+	case num := <-numChan:
+		if num == "" {
+			abort()
+			return nil, errors.New("xvfb exited without reporting a display")
+		}
+		d.name = ":" + num
+		return d, nil
+	case <-d.exited:
+		return nil, errors.New("xvfb exited before reporting a display")
+	case <-time.After(timeout):
+		abort()
+		return nil, fmt.Errorf("xvfb did not report a display within %s", timeout)
+	case <-ctx.Done():
+		abort()
+		return nil, ctx.Err()
+	}
+}
+
+// stop terminates the Xvfb server, killing it if it does not exit in time.
+func (d *virtualDisplay) stop() {
+	_ = terminateProcess(d.cmd)
+
+	select {
+	case <-d.exited:
+	case <-time.After(defaultCloseGracePeriod):
+		_ = killProcess(d.cmd)
+		<-d.exited
+	}
+}
+
+// needsDisplay reports whether a virtual display has to be started: it is
+// configured, the browser is headful and its environment has no DISPLAY.
+func (c *BrowserConfig) needsDisplay() bool {
+	if c.VirtualDisplay == nil {
+		return false
+	}
+
+	if _, ok := c.headless(); ok {
+		return false
+	}
+
+	// without Envs the browser inherits the environment of this process
+	if c.Envs == nil {
+		return os.Getenv("DISPLAY") == ""
+	}
+	for _, env := range c.Envs {
+		if v, ok := strings.CutPrefix(env, "DISPLAY="); ok && v != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// startDisplay starts the virtual display when the configuration needs one.
+func (b *browser) startDisplay(ctx context.Context, timeout time.Duration) error {
+	if !b.config.needsDisplay() {
+		return nil
+	}
+
+	d, err := startVirtualDisplay(ctx, b.config.VirtualDisplay, timeout)
+	if err != nil {
+		return fmt.Errorf("unable to start virtual display: %w", err)
+	}
+	b.logger.Debug("started virtual display", "display", d.name, "pid", d.cmd.Process.Pid)
+
+	b.mux.Lock()
+	b.display = d
+	b.mux.Unlock()
+
+	return nil
+}
+
+// stopDisplay stops the virtual display, if any.
+func (b *browser) stopDisplay() {
+	b.mux.Lock()
+	d := b.display
+	b.display = nil
+	b.mux.Unlock()
+
+	if d == nil {
+		return
+	}
+
+	b.logger.Debug("stopping virtual display", "display", d.name)
+	d.stop()
+}
+
+// browserEnv returns the environment of the browser process, pointing
+// DISPLAY to the virtual display when one is running.
+func (b *browser) browserEnv() []string {
+	b.mux.RLock()
+	d := b.display
+	b.mux.RUnlock()
+
+	if d == nil {
+		return b.config.Envs
+	}
+
+	env := b.config.Envs
+	if env == nil {
+		env = os.Environ()
+	}
+	return append(env[:len(env):len(env)], "DISPLAY="+d.name)
+}
@@ -0,0 +1,347 @@
+package gopilot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/mafredri/cdp"
+	cdpbrowser "github.com/mafredri/cdp/protocol/browser"
+)
+
+// ErrDownloadCanceled is returned by WaitForDownload when the download is canceled.
+var ErrDownloadCanceled = errors.New("download canceled")
+
+// DownloadState is the state of a download.
+type DownloadState string
+
+const (
+	DownloadInProgress DownloadState = "inProgress"
+	DownloadCompleted  DownloadState = "completed"
+	DownloadCanceled   DownloadState = "canceled"
+)
+
+// DownloadEvent reports the beginning and progress of a download.
+type DownloadEvent struct {
+	GUID              string // GUID identifies the download.
+	URL               string // URL of the resource being downloaded.
+	SuggestedFilename string // SuggestedFilename is the name proposed by the server or the page.
+	FrameID           string // FrameID of the frame that caused the download.
+
+	State         DownloadState
+	TotalBytes    float64 // TotalBytes is the expected size, zero when unknown.
+	ReceivedBytes float64
+
+	// FilePath is where the file is saved, files are named after their GUID
+	// inside the download directory. Only set once the download completed.
+	FilePath string
+}
+
+// DownloadCallback is called when a download begins and on each progress update.
+type DownloadCallback func(ctx context.Context, ev *DownloadEvent)
+
+// DownloadHandle is a handle for managing download callbacks.
+type DownloadHandle struct {
+	id uint64
+}
+
+var downloadHandleID atomic.Uint64
+
+// BrowserEnableDownloadsInput contains parameters for EnableDownloads.
+type BrowserEnableDownloadsInput struct {
+	// Dir is the directory downloads are saved to, it is created if missing.
+	// Defaults to a "Downloads" directory inside the data dir of a launched browser,
+	// which is removed along with a temporary data dir on Close.
+	Dir string
+}
+
+// BrowserEnableDownloadsOutput contains the directory downloads are saved to.
+type BrowserEnableDownloadsOutput struct {
+	Dir string
+}
+
+// EnableDownloads allows downloads in the default browser context and starts reporting their progress.
+func (b *browser) EnableDownloads(ctx context.Context, in *BrowserEnableDownloadsInput) (*BrowserEnableDownloadsOutput, error) {
+	dir, err := b.setDownloadBehavior(ctx, in.Dir, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mux.Lock()
+	b.downloadDir = dir
+	b.mux.Unlock()
+
+	return &BrowserEnableDownloadsOutput{Dir: dir}, nil
+}
+
+// setDownloadBehavior allows downloads into dir for the browser context
+// (default one when nil) and starts the download event loop.
+func (b *browser) setDownloadBehavior(ctx context.Context, dir string, contextID *cdpbrowser.ContextID) (string, error) {
+	if dir == "" {
+		if b.remote || b.datadir == "" {
+			return "", errors.New("download dir is required for a remote browser")
+		}
+		dir = filepath.Join(b.datadir, "Downloads")
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if !b.remote {
+		if err = os.MkdirAll(dir, 0o755); err != nil {
+			return "", err
+		}
+	}
+
+	client, err := b.browserClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if err = b.watchDownloads(ctx, client); err != nil {
+		return "", err
+	}
+
+	args := cdpbrowser.NewSetDownloadBehaviorArgs("allowAndName").
+		SetDownloadPath(dir).
+		SetEventsEnabled(true)
+	if contextID != nil {
+		args.SetBrowserContextID(*contextID)
+	}
+
+	if err = client.Browser.SetDownloadBehavior(ctx, args); err != nil {
+		return "", err
+	}
+
+	b.mux.Lock()
+	b.downloadDirs = append(b.downloadDirs, dir)
+	b.mux.Unlock()
+
+	b.logger.Debug("downloads enabled", "dir", dir, "context_id", contextID)
+
+	return dir, nil
+}
+
+// OnDownload registers a callback for download events.
+// Downloads must be enabled with EnableDownloads for events to be emitted.
+func (b *browser) OnDownload(_ context.Context, cb DownloadCallback) *DownloadHandle {
+	handle := &DownloadHandle{id: downloadHandleID.Add(1)}
+
+	b.mux.Lock()
+	b.downloadListeners[handle] = cb
+	b.mux.Unlock()
+
+	return handle
+}
+
+// RemoveDownloadListener removes a download callback using the provided handle.
+func (b *browser) RemoveDownloadListener(handle *DownloadHandle) {
+	b.mux.Lock()
+	delete(b.downloadListeners, handle)
+	b.mux.Unlock()
+}
+
+// BrowserWaitForDownloadInput contains parameters for WaitForDownload.
+type BrowserWaitForDownloadInput struct {
+	// Match is an optional predicate the beginning download must satisfy.
+	Match func(ev *DownloadEvent) bool
+
+	// Trigger is called once the listener is in place, it should perform the
+	// action starting the download, such as an Element.Click.
+	Trigger func(ctx context.Context) error
+}
+
+// BrowserWaitForDownloadOutput describes a completed download.
+type BrowserWaitForDownloadOutput struct {
+	GUID              string
+	URL               string
+	SuggestedFilename string
+	FilePath          string
+	TotalBytes        float64
+}
+
+// WaitForDownload waits until a download begins and completes.
+func (b *browser) WaitForDownload(ctx context.Context, in *BrowserWaitForDownloadInput) (*BrowserWaitForDownloadOutput, error) {
+	evChan := make(chan *DownloadEvent, 16)
+
+	var guid atomic.Value
+	handle := b.OnDownload(ctx, func(_ context.Context, ev *DownloadEvent) {
+		if g, ok := guid.Load().(string); ok {
+			if ev.GUID != g {
+				return
+			}
+		} else {
+			if in.Match != nil && !in.Match(ev) {
+				return
+			}
+			guid.Store(ev.GUID)
+		}
+
+		if ev.State == DownloadInProgress {
+			return
+		}
+
+		select {
+		case evChan <- ev:
+		default:
+		}
+	})
+	defer b.RemoveDownloadListener(handle)
+
+	if in.Trigger != nil {
+		if err := in.Trigger(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case ev := <-evChan:
+		if ev.State == DownloadCanceled {
+			return nil, ErrDownloadCanceled
+		}
+		return &BrowserWaitForDownloadOutput{
+			GUID:              ev.GUID,
+			URL:               ev.URL,
+			SuggestedFilename: ev.SuggestedFilename,
+			FilePath:          ev.FilePath,
+			TotalBytes:        ev.TotalBytes,
+		}, nil
+	}
+}
+
+// watchDownloads starts the loop dispatching download events, only once per connection.
+func (b *browser) watchDownloads(ctx context.Context, client *cdp.Client) error {
+	b.watchMux.Lock()
+	defer b.watchMux.Unlock()
+
+	if b.downloadCancel != nil {
+		return nil
+	}
+
+	wctx, cancel := context.WithCancel(context.Background())
+
+	begin, err := client.Browser.DownloadWillBegin(wctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	progress, err := client.Browser.DownloadProgress(wctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	// the progress of a download must not be received before its beginning
+	if err = cdp.Sync(begin, progress); err != nil {
+		cancel()
+		return err
+	}
+
+	b.downloadCancel = cancel
+	go b.runDownloads(wctx, begin, progress)
+
+	return nil
+}
+
+// stopWatchingDownloads stops the download event loop.
+func (b *browser) stopWatchingDownloads() {
+	b.watchMux.Lock()
+	defer b.watchMux.Unlock()
+
+	if b.downloadCancel != nil {
+		b.downloadCancel()
+		b.downloadCancel = nil
+	}
+}
+
+func (b *browser) runDownloads(ctx context.Context, begin cdpbrowser.DownloadWillBeginClient, progress cdpbrowser.DownloadProgressClient) {
+	defer begin.Close()
+	defer progress.Close()
+
+	downloads := map[string]*DownloadEvent{}
+
+	for {
+		var ev DownloadEvent
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-begin.Ready():
+			rp, err := begin.Recv()
+			if err != nil {
+				return
+			}
+			ev = DownloadEvent{
+				GUID:              rp.GUID,
+				URL:               rp.URL,
+				SuggestedFilename: rp.SuggestedFilename,
+				FrameID:           string(rp.FrameID),
+				State:             DownloadInProgress,
+			}
+			downloads[rp.GUID] = &ev
+
+		case <-progress.Ready():
+			rp, err := progress.Recv()
+			if err != nil {
+				return
+			}
+			if d, ok := downloads[rp.GUID]; ok {
+				ev = *d
+			}
+			ev.GUID = rp.GUID
+			ev.State = DownloadState(rp.State)
+			ev.TotalBytes = rp.TotalBytes
+			ev.ReceivedBytes = rp.ReceivedBytes
+
+			if ev.State != DownloadInProgress {
+				delete(downloads, rp.GUID)
+			}
+			if ev.State == DownloadCompleted {
+				ev.FilePath = b.downloadPath(rp.GUID)
+			}
+		}
+
+		b.dispatchDownloadEvent(ctx, &ev)
+	}
+}
+
+// downloadPath locates the file of a completed download among the download dirs.
+func (b *browser) downloadPath(guid string) string {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+
+	for _, dir := range b.downloadDirs {
+		p := filepath.Join(dir, guid)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+
+	// remote browsers save to their own filesystem
+	if len(b.downloadDirs) > 0 {
+		return filepath.Join(b.downloadDirs[0], guid)
+	}
+	return ""
+}
+
+// dispatchDownloadEvent invokes every registered download callback.
+func (b *browser) dispatchDownloadEvent(ctx context.Context, ev *DownloadEvent) {
+	b.mux.RLock()
+	callbacks := make([]DownloadCallback, 0, len(b.downloadListeners))
+	for _, cb := range b.downloadListeners {
+		callbacks = append(callbacks, cb)
+	}
+	b.mux.RUnlock()
+
+	b.logger.Debug("download event", "guid", ev.GUID, "state", ev.State, "received", ev.ReceivedBytes)
+
+	for _, cb := range callbacks {
+		cb(ctx, ev)
+	}
+}
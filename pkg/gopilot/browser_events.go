@@ -0,0 +1,377 @@
+package gopilot
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/mafredri/cdp"
+	"github.com/mafredri/cdp/protocol/target"
+)
+
+// PageEventType identifies the kind of target lifecycle event.
+type PageEventType string
+
+const (
+	PageCreated   PageEventType = "created"   // A new page was opened, e.g. a popup or a new tab.
+	PageDestroyed PageEventType = "destroyed" // A page was closed.
+	PageCrashed   PageEventType = "crashed"   // A page renderer crashed.
+)
+
+// PageEvent describes a change in the lifecycle of a page target.
+type PageEvent struct {
+	Type             PageEventType
+	TargetID         string // TargetID of the page the event refers to.
+	OpenerID         string // OpenerID is the target that opened the page, if any.
+	BrowserContextID string // BrowserContextID the page belongs to, if any.
+	URL              string // URL of the page when it was created.
+
+	Page Page  // Page is the attached page, only set for PageCreated.
+	Err  error // Err holds the attach failure of a PageCreated event.
+
+	Status    string // Status is the termination status of a PageCrashed event.
+	ErrorCode int    // ErrorCode is the termination error code of a PageCrashed event.
+}
+
+// PageEventCallback is called for every page lifecycle event.
+// Callbacks run on the event loop, blocking in them delays the next events.
+type PageEventCallback func(ctx context.Context, ev *PageEvent)
+
+// PageEventHandle is a handle for managing page event callbacks.
+type PageEventHandle struct {
+	id uint64
+}
+
+var pageEventHandleID atomic.Uint64
+
+// OnPage registers a callback for page lifecycle events and starts
+// discovering targets if it was not already.
+func (b *browser) OnPage(ctx context.Context, cb PageEventCallback) (*PageEventHandle, error) {
+	if err := b.watchTargets(ctx); err != nil {
+		return nil, err
+	}
+
+	handle := &PageEventHandle{id: pageEventHandleID.Add(1)}
+
+	b.mux.Lock()
+	b.pageListeners[handle] = cb
+	b.mux.Unlock()
+
+	return handle, nil
+}
+
+// RemovePageListener removes a page event callback using the provided handle.
+func (b *browser) RemovePageListener(handle *PageEventHandle) {
+	b.mux.Lock()
+	delete(b.pageListeners, handle)
+	b.mux.Unlock()
+}
+
+// BrowserWaitForPageInput contains parameters for WaitForPage.
+type BrowserWaitForPageInput struct {
+	// OpenerID only matches pages opened by this target, e.g. Page.GetTargetID().
+	OpenerID string
+
+	// Match is an optional predicate the created page event must satisfy.
+	Match func(ev *PageEvent) bool
+
+	// Trigger is called once the listener is in place, it should perform the
+	// action that opens the page, such as clicking a target=_blank link.
+	Trigger func(ctx context.Context) error
+}
+
+// BrowserWaitForPageOutput contains the page that was opened.
+type BrowserWaitForPageOutput struct {
+	Page     Page
+	OpenerID string
+}
+
+// WaitForPage waits until a new page matching the input is created.
+func (b *browser) WaitForPage(ctx context.Context, in *BrowserWaitForPageInput) (*BrowserWaitForPageOutput, error) {
+	evChan := make(chan *PageEvent, 1)
+
+	handle, err := b.OnPage(ctx, func(_ context.Context, ev *PageEvent) {
+		if ev.Type != PageCreated {
+			return
+		}
+		if in.OpenerID != "" && ev.OpenerID != in.OpenerID {
+			return
+		}
+		if in.Match != nil && !in.Match(ev) {
+			return
+		}
+
+		select {
+		case evChan <- ev:
+		default:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer b.RemovePageListener(handle)
+
+	if in.Trigger != nil {
+		if err = in.Trigger(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case ev := <-evChan:
+		if ev.Err != nil {
+			return nil, ev.Err
+		}
+		return &BrowserWaitForPageOutput{Page: ev.Page, OpenerID: ev.OpenerID}, nil
+	}
+}
+
+// watchTargets enables target discovery on the browser connection and
+// starts the loop dispatching page events, only once per connection.
+func (b *browser) watchTargets(ctx context.Context) error {
+	b.watchMux.Lock()
+	defer b.watchMux.Unlock()
+
+	if b.watchCancel != nil {
+		return nil
+	}
+
+	client, err := b.browserClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	// targets that exist before discovery are not reported as created
+	rp, err := client.Target.GetTargets(ctx, target.NewGetTargetsArgs())
+	if err != nil {
+		return err
+	}
+	known := map[target.ID]bool{}
+	for _, t := range rp.TargetInfos {
+		known[t.TargetID] = true
+	}
+
+	wctx, cancel := context.WithCancel(context.Background())
+
+	created, err := client.Target.TargetCreated(wctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	destroyed, err := client.Target.TargetDestroyed(wctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	crashed, err := client.Target.TargetCrashed(wctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	// receive the events in order of arrival
+	if err = cdp.Sync(created, destroyed, crashed); err != nil {
+		cancel()
+		return err
+	}
+
+	if err = client.Target.SetDiscoverTargets(ctx, target.NewSetDiscoverTargetsArgs(true)); err != nil {
+		cancel()
+		return err
+	}
+
+	b.watchCancel = cancel
+	w := &targetWatcher{
+		browser:   b,
+		created:   created,
+		destroyed: destroyed,
+		crashed:   crashed,
+		known:     known,
+		infos:     map[target.ID]target.Info{},
+	}
+	go w.run(wctx)
+
+	return nil
+}
+
+// stopWatchingTargets stops the event loop, it is restarted by the next OnPage.
+func (b *browser) stopWatchingTargets() {
+	b.watchMux.Lock()
+	defer b.watchMux.Unlock()
+
+	if b.watchCancel != nil {
+		b.watchCancel()
+		b.watchCancel = nil
+	}
+}
+
+// targetWatcher turns Target domain events into PageEvent values.
+type targetWatcher struct {
+	browser   *browser
+	created   target.CreatedClient
+	destroyed target.DestroyedClient
+	crashed   target.CrashedClient
+	known     map[target.ID]bool
+	infos     map[target.ID]target.Info
+}
+
+func (w *targetWatcher) run(ctx context.Context) {
+	defer w.created.Close()
+	defer w.destroyed.Close()
+	defer w.crashed.Close()
+
+	for {
+		var ev *PageEvent
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-w.created.Ready():
+			rp, err := w.created.Recv()
+			if err != nil {
+				return
+			}
+			ev = w.onCreated(ctx, rp.TargetInfo)
+
+		case <-w.destroyed.Ready():
+			rp, err := w.destroyed.Recv()
+			if err != nil {
+				return
+			}
+			ev = w.onDestroyed(rp.TargetID)
+
+		case <-w.crashed.Ready():
+			rp, err := w.crashed.Recv()
+			if err != nil {
+				return
+			}
+			ev = w.newEvent(PageCrashed, rp.TargetID)
+			if ev != nil {
+				ev.Status = rp.Status
+				ev.ErrorCode = rp.ErrorCode
+			}
+		}
+
+		if ev != nil {
+			w.browser.dispatchPageEvent(ctx, ev)
+		}
+	}
+}
+
+// newEvent builds an event for a known page target, nil for other target types.
+func (w *targetWatcher) newEvent(typ PageEventType, id target.ID) *PageEvent {
+	info, ok := w.infos[id]
+	if !ok {
+		return nil
+	}
+
+	ev := &PageEvent{Type: typ, TargetID: string(id), URL: info.URL}
+	if info.OpenerID != nil {
+		ev.OpenerID = string(*info.OpenerID)
+	}
+	if info.BrowserContextID != nil {
+		ev.BrowserContextID = string(*info.BrowserContextID)
+	}
+	return ev
+}
+
+func (w *targetWatcher) onCreated(ctx context.Context, info target.Info) *PageEvent {
+	if info.Type != "page" {
+		return nil
+	}
+	w.infos[info.TargetID] = info
+
+	if w.known[info.TargetID] {
+		return nil
+	}
+
+	ev := w.newEvent(PageCreated, info.TargetID)
+	ev.Page, ev.Err = w.browser.trackTarget(ctx, string(info.TargetID), ev.BrowserContextID)
+
+	return ev
+}
+
+func (w *targetWatcher) onDestroyed(id target.ID) *PageEvent {
+	ev := w.newEvent(PageDestroyed, id)
+	delete(w.infos, id)
+	delete(w.known, id)
+
+	if ev != nil {
+		w.browser.untrackTarget(string(id))
+	}
+	return ev
+}
+
+// trackTarget returns the tracked page of the target, attaching to it when needed.
+// Pages of a browser context created through NewContext belong to that context
+// and use its proxy, they are not added to the browser pages.
+func (b *browser) trackTarget(ctx context.Context, id string, contextID string) (Page, error) {
+	if c := b.contextFor(contextID); c != nil {
+		p, err := b.attachTargetID(ctx, id, c.proxyConfig())
+		if err != nil {
+			return nil, err
+		}
+		c.addPage(p)
+		return p, nil
+	}
+
+	p, err := b.attachTargetID(ctx, id, b.config.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	b.addPage(p)
+
+	return p, nil
+}
+
+// untrackTarget marks the page of a destroyed target as closed, whether it
+// belongs to the browser or to one of its contexts.
+func (b *browser) untrackTarget(id string) {
+	b.attachMux.Lock()
+	a := b.attached[id]
+	delete(b.attached, id)
+	b.attachMux.Unlock()
+
+	if a != nil {
+		select {
+		case <-a.done:
+			if a.page != nil {
+				a.page.(*page).markClosed()
+			}
+		default:
+			// still attaching, the page will fail on its first call
+		}
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	for i, p := range b.pages {
+		p := p.(*page)
+		if p.id != id {
+			continue
+		}
+
+		p.markClosed()
+		b.pages = append(b.pages[:i], b.pages[i+1:]...)
+		return
+	}
+}
+
+// dispatchPageEvent invokes every registered page event callback.
+func (b *browser) dispatchPageEvent(ctx context.Context, ev *PageEvent) {
+	b.mux.RLock()
+	callbacks := make([]PageEventCallback, 0, len(b.pageListeners))
+	for _, cb := range b.pageListeners {
+		callbacks = append(callbacks, cb)
+	}
+	b.mux.RUnlock()
+
+	b.logger.Debug("page event", "type", ev.Type, "target_id", ev.TargetID, "opener_id", ev.OpenerID)
+
+	for _, cb := range callbacks {
+		cb(ctx, ev)
+	}
+}
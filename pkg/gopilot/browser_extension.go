@@ -0,0 +1,237 @@
+package gopilot
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mafredri/cdp"
+	"github.com/mafredri/cdp/devtool"
+	"github.com/mafredri/cdp/protocol/target"
+	"github.com/mafredri/cdp/rpcc"
+)
+
+// extensionScheme is the URL scheme of extension resources.
+const extensionScheme = "chrome-extension"
+
+// Extension represents a loaded extension, backed by its service worker
+// (manifest v3) or background page (manifest v2) target.
+type Extension interface {
+	// GetID returns the extension id.
+	GetID() string
+
+	// GetURL returns the URL of the extension background target.
+	GetURL() string
+
+	// Evaluate executes a JavaScript expression in the extension background context.
+	Evaluate(ctx context.Context, in *PageEvaluateInput) (*PageEvaluateOutput, error)
+
+	// OpenPage opens an extension page, such as its popup or options page, in a new tab.
+	OpenPage(ctx context.Context, in *ExtensionOpenPageInput) (*ExtensionOpenPageOutput, error)
+
+	// Close detaches from the extension background target.
+	Close() error
+}
+
+// BrowserGetExtensionsInput represents the input for listing extensions.
+type BrowserGetExtensionsInput struct {
+	// Wait polls until every extension of BrowserConfig.Extensions has a
+	// background target or ctx is done. Service workers start shortly after
+	// the browser, so they may be missing right after Open.
+	Wait bool
+}
+
+// BrowserGetExtensionsOutput represents the output of listing extensions.
+type BrowserGetExtensionsOutput struct {
+	Extensions []Extension
+}
+
+// ExtensionOpenPageInput represents the input for opening an extension page.
+type ExtensionOpenPageInput struct {
+	// Path is the page path within the extension, e.g. "popup.html".
+	Path string
+}
+
+// ExtensionOpenPageOutput represents the output of opening an extension page.
+type ExtensionOpenPageOutput struct {
+	Page Page
+}
+
+type extension struct {
+	id      string
+	target  target.Info
+	browser *browser
+
+	mux    sync.Mutex
+	conn   *rpcc.Conn
+	client *cdp.Client
+}
+
+// GetExtensions returns the extensions with a running background target.
+func (b *browser) GetExtensions(ctx context.Context, in *BrowserGetExtensionsInput) (*BrowserGetExtensionsOutput, error) {
+	for {
+		infos, err := b.listExtensionTargets(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if in == nil || !in.Wait || len(infos) >= len(b.config.Extensions) {
+			out := &BrowserGetExtensionsOutput{}
+			for _, info := range infos {
+				out.Extensions = append(out.Extensions, &extension{
+					id:      extensionID(info.URL),
+					target:  info,
+					browser: b,
+				})
+			}
+			return out, nil
+		}
+
+		if err = sleepWithCtx(ctx, 100*time.Millisecond); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// listExtensionTargets returns the service worker and background page
+// targets that belong to extensions.
+func (b *browser) listExtensionTargets(ctx context.Context) ([]target.Info, error) {
+	client, err := b.browserClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rp, err := client.Target.GetTargets(ctx, target.NewGetTargetsArgs())
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []target.Info
+	for _, t := range rp.TargetInfos {
+		if t.Type != string(devtool.ServiceWorker) && t.Type != string(devtool.BackgroundPage) {
+			continue
+		}
+		if extensionID(t.URL) == "" {
+			continue
+		}
+		infos = append(infos, t)
+	}
+	return infos, nil
+}
+
+// extensionID returns the extension id of an extension URL, or "" when the
+// URL does not belong to an extension.
+func extensionID(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != extensionScheme {
+		return ""
+	}
+	return u.Host
+}
+
+func (e *extension) GetID() string {
+	return e.id
+}
+
+func (e *extension) GetURL() string {
+	return e.target.URL
+}
+
+func (e *extension) Evaluate(ctx context.Context, in *PageEvaluateInput) (*PageEvaluateOutput, error) {
+	client, err := e.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluate(ctx, client, in)
+}
+
+// dial connects to the background target on first use.
+func (e *extension) dial(ctx context.Context) (*cdp.Client, error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	if e.client != nil {
+		return e.client, nil
+	}
+
+	var conn *rpcc.Conn
+	var err error
+
+	if e.browser.config.FlattenSessions {
+		conn, err = e.browser.dialSession(ctx, e.target.TargetID)
+	} else {
+		conn, err = e.browser.dialTarget(ctx, string(e.target.TargetID))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e.browser.logger.Debug("attached to extension", "extension_id", e.id, "target_id", e.target.TargetID)
+	e.conn = conn
+	e.client = cdp.NewClient(conn)
+
+	return e.client, nil
+}
+
+func (e *extension) OpenPage(ctx context.Context, in *ExtensionOpenPageInput) (*ExtensionOpenPageOutput, error) {
+	u := fmt.Sprintf("%s://%s/%s", extensionScheme, e.id, strings.TrimPrefix(in.Path, "/"))
+
+	client, err := e.browser.browserClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rp, err := client.Target.CreateTarget(ctx, target.NewCreateTargetArgs(u))
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := e.browser.attachTargetID(ctx, string(rp.TargetID), e.browser.config.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	e.browser.addPage(p)
+
+	return &ExtensionOpenPageOutput{Page: p}, nil
+}
+
+func (e *extension) Close() error {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	if e.conn == nil {
+		return nil
+	}
+
+	err := e.conn.Close()
+	e.conn = nil
+	e.client = nil
+
+	return err
+}
+
+// dialTarget dials the websocket of the target with the given id.
+func (b *browser) dialTarget(ctx context.Context, id string) (*rpcc.Conn, error) {
+	dt := b.GetDevToolClient()
+	if dt == nil {
+		return nil, ErrBrowserClosed
+	}
+
+	targets, err := dt.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range targets {
+		if t.ID == id {
+			return rpcc.DialContext(ctx, t.WebSocketDebuggerURL)
+		}
+	}
+
+	return nil, fmt.Errorf("target %s not found", id)
+}
@@ -0,0 +1,208 @@
+package gopilot
+
+import (
+	"bytes"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxOutputLineSize flushes unterminated output as a line once exceeded.
+const maxOutputLineSize = 64 * 1024
+
+// OutputLine is a line written by the browser process to stdout or stderr.
+// Chrome log lines look like "[pid:tid:MMDD/HHMMSS.micro:LEVEL:file.cc(123)] message",
+// their prefix is parsed into the fields below.
+type OutputLine struct {
+	Time    time.Time  // Time is when the line was read.
+	Stream  string     // Stream is "stdout" or "stderr".
+	Level   slog.Level // Level is the log level of the prefix, Info for lines without one.
+	PID     int        // PID is the process id of the prefix, zero when missing.
+	TID     int        // TID is the thread id of the prefix, zero when missing.
+	Source  string     // Source is the file and line of the prefix, e.g. "gpu_init.cc(1234)".
+	Message string     // Message is the line without its prefix.
+	Raw     string     // Raw is the line as written by the browser.
+}
+
+// Output returns the most recent lines written by the browser process, oldest first.
+// BrowserConfig.OutputBufferSize sets how many are kept.
+func (b *browser) Output() []OutputLine {
+	b.mux.RLock()
+	out := b.output
+	b.mux.RUnlock()
+
+	if out == nil {
+		return nil
+	}
+	return out.Values()
+}
+
+// outputTail returns the raw text of the last n output lines, for diagnostics.
+func (b *browser) outputTail(n int) []string {
+	lines := b.Output()
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	tail := make([]string, 0, len(lines))
+	for _, l := range lines {
+		tail = append(tail, l.Raw)
+	}
+	return tail
+}
+
+// recordOutput stores a line in the output ring, writes it to the configured
+// sink and logs it.
+func (b *browser) recordOutput(line OutputLine) {
+	b.mux.RLock()
+	out := b.output
+	b.mux.RUnlock()
+
+	if out != nil {
+		out.Add(line)
+	}
+
+	if w := b.config.Output; w != nil {
+		b.outputMux.Lock()
+		_, err := w.Write([]byte(line.Raw + "\n"))
+		b.outputMux.Unlock()
+		if err != nil {
+			b.logger.Debug("unable to write browser output", "error", err)
+		}
+	}
+
+	b.logger.Debug(
+		"browser output",
+		"stream", line.Stream,
+		"level", line.Level,
+		"source", line.Source,
+		"message", line.Message,
+	)
+}
+
+// outputCapture collects the output of a single browser start: it keeps the
+// tail for launch errors and reports the "DevTools listening on" line.
+type outputCapture struct {
+	browser  *browser
+	tail     *ring[string]
+	devtools chan string
+}
+
+func (c *outputCapture) add(stream string, raw string) {
+	line := parseOutputLine(stream, raw)
+
+	if strings.Contains(line.Message, "DevTools listening on") {
+		// nobody reads past the first one
+		select {
+		case c.devtools <- line.Message:
+		default:
+		}
+	}
+
+	c.tail.Add(raw)
+	c.browser.recordOutput(line)
+}
+
+// writer returns an io.Writer splitting what is written to it into lines of stream.
+func (c *outputCapture) writer(stream string) *outputWriter {
+	return &outputWriter{stream: stream, capture: c}
+}
+
+// outputWriter splits a stream of the browser process into lines.
+// exec.Cmd writes from a single goroutine per stream, mux only guards flush.
+type outputWriter struct {
+	mux     sync.Mutex
+	stream  string
+	capture *outputCapture
+	buf     []byte
+}
+
+// Write implements io.Writer.
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.capture.add(w.stream, strings.TrimRight(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+
+	if len(w.buf) > maxOutputLineSize {
+		w.capture.add(w.stream, string(w.buf))
+		w.buf = nil
+	}
+
+	return len(p), nil
+}
+
+// flush emits the unterminated remainder, if any.
+func (w *outputWriter) flush() {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	if len(w.buf) > 0 {
+		w.capture.add(w.stream, string(w.buf))
+		w.buf = nil
+	}
+}
+
+// parseOutputLine parses the Chrome log prefix of raw, if present.
+func parseOutputLine(stream string, raw string) OutputLine {
+	line := OutputLine{
+		Time:    time.Now(),
+		Stream:  stream,
+		Level:   slog.LevelInfo,
+		Message: raw,
+		Raw:     raw,
+	}
+
+	if !strings.HasPrefix(raw, "[") {
+		return line
+	}
+	end := strings.Index(raw, "]")
+	if end < 0 {
+		return line
+	}
+
+	// the date field is optional, so the level is searched for instead of indexed
+	parts := strings.Split(raw[1:end], ":")
+	for i, part := range parts {
+		level, ok := parseOutputLevel(part)
+		if !ok || i == 0 {
+			continue
+		}
+
+		line.Level = level
+		line.PID, _ = strconv.Atoi(parts[0])
+		if i >= 2 {
+			line.TID, _ = strconv.Atoi(parts[1])
+		}
+		line.Source = strings.Join(parts[i+1:], ":")
+		line.Message = strings.TrimSpace(raw[end+1:])
+		break
+	}
+
+	return line
+}
+
+// parseOutputLevel maps a Chrome log severity to a slog level.
+func parseOutputLevel(s string) (slog.Level, bool) {
+	switch {
+	case s == "INFO":
+		return slog.LevelInfo, true
+	case s == "WARNING":
+		return slog.LevelWarn, true
+	case s == "ERROR", s == "FATAL":
+		return slog.LevelError, true
+	case strings.HasPrefix(s, "VERBOSE"):
+		return slog.LevelDebug, true
+	}
+	return 0, false
+}
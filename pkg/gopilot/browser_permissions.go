@@ -0,0 +1,119 @@
+package gopilot
+
+import (
+	"context"
+
+	cdpbrowser "github.com/mafredri/cdp/protocol/browser"
+)
+
+// PermissionSetting is the state a permission is overridden to.
+type PermissionSetting string
+
+const (
+	PermissionGranted PermissionSetting = "granted" // The permission is granted without prompting.
+	PermissionDenied  PermissionSetting = "denied"  // The permission is denied without prompting.
+	PermissionPrompt  PermissionSetting = "prompt"  // The browser asks the user, its default behavior.
+)
+
+// BrowserSetPermissionsInput contains the permissions to override.
+type BrowserSetPermissionsInput struct {
+	// Origin the permissions apply to, e.g. "https://example.com".
+	// An empty Origin applies to all origins.
+	Origin string
+
+	// Permissions are web permission names, such as "geolocation",
+	// "notifications", "camera", "microphone", "clipboard-read" or "clipboard-write".
+	Permissions []string
+
+	// Setting is the state the permissions are set to.
+	Setting PermissionSetting
+}
+
+// BrowserSetPermissionsOutput is returned after setting permissions successfully.
+type BrowserSetPermissionsOutput struct{}
+
+// BrowserResetPermissionsInput contains parameters for ResetPermissions.
+type BrowserResetPermissionsInput struct{}
+
+// BrowserResetPermissionsOutput is returned after resetting permissions successfully.
+type BrowserResetPermissionsOutput struct{}
+
+// SetPermissions grants, denies or resets to prompt the permissions of an origin
+// in the default browser context.
+func (b *browser) SetPermissions(ctx context.Context, in *BrowserSetPermissionsInput) (*BrowserSetPermissionsOutput, error) {
+	if err := b.setPermissions(ctx, in, nil); err != nil {
+		return nil, err
+	}
+	return &BrowserSetPermissionsOutput{}, nil
+}
+
+// ResetPermissions clears every permission override of the default browser context.
+func (b *browser) ResetPermissions(ctx context.Context, _ *BrowserResetPermissionsInput) (*BrowserResetPermissionsOutput, error) {
+	if err := b.resetPermissions(ctx, nil); err != nil {
+		return nil, err
+	}
+	return &BrowserResetPermissionsOutput{}, nil
+}
+
+// setPermissions overrides the permissions in the browser context (default one when nil).
+func (b *browser) setPermissions(ctx context.Context, in *BrowserSetPermissionsInput, contextID *cdpbrowser.ContextID) error {
+	client, err := b.browserClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range in.Permissions {
+		desc := cdpbrowser.PermissionDescriptor{Name: name}
+		if name == "fullscreen" {
+			// fullscreen can only be overridden for requests without user gesture
+			allow := true
+			desc.AllowWithoutGesture = &allow
+		}
+
+		args := cdpbrowser.NewSetPermissionArgs(desc, cdpbrowser.PermissionSetting(in.Setting))
+		if in.Origin != "" {
+			args.SetOrigin(in.Origin)
+		}
+		if contextID != nil {
+			args.SetBrowserContextID(*contextID)
+		}
+
+		if err = client.Browser.SetPermission(ctx, args); err != nil {
+			return err
+		}
+		b.logger.Debug("permission set", "name", name, "setting", in.Setting, "origin", in.Origin, "context_id", contextID)
+	}
+
+	return nil
+}
+
+// resetPermissions clears the permission overrides of the browser context (default one when nil).
+func (b *browser) resetPermissions(ctx context.Context, contextID *cdpbrowser.ContextID) error {
+	client, err := b.browserClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	args := cdpbrowser.NewResetPermissionsArgs()
+	if contextID != nil {
+		args.SetBrowserContextID(*contextID)
+	}
+
+	return client.Browser.ResetPermissions(ctx, args)
+}
+
+// SetPermissions grants, denies or resets to prompt the permissions of an origin in the context.
+func (c *browserContext) SetPermissions(ctx context.Context, in *BrowserSetPermissionsInput) (*BrowserSetPermissionsOutput, error) {
+	if err := c.browser.setPermissions(ctx, in, &c.id); err != nil {
+		return nil, err
+	}
+	return &BrowserSetPermissionsOutput{}, nil
+}
+
+// ResetPermissions clears every permission override of the context.
+func (c *browserContext) ResetPermissions(ctx context.Context, _ *BrowserResetPermissionsInput) (*BrowserResetPermissionsOutput, error) {
+	if err := c.browser.resetPermissions(ctx, &c.id); err != nil {
+		return nil, err
+	}
+	return &BrowserResetPermissionsOutput{}, nil
+}
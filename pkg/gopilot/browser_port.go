@@ -0,0 +1,77 @@
+package gopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// devToolsActivePortFile is written by chrome into the user data dir once
+// the remote debugging server is listening.
+const devToolsActivePortFile = "DevToolsActivePort"
+
+// isAutoDebugPort reports whether the port should be picked by the browser itself.
+func isAutoDebugPort(port string) bool {
+	return port == "" || port == "0"
+}
+
+// readDevToolsActivePort parses the DevToolsActivePort file inside dir.
+// The first line holds the port and the second one the browser websocket path.
+func readDevToolsActivePort(dir string) (string, string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, devToolsActivePortFile))
+	if err != nil {
+		return "", "", err
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) < 2 {
+		return "", "", errors.New("incomplete devtools active port file")
+	}
+
+	port := strings.TrimSpace(lines[0])
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 {
+		return "", "", fmt.Errorf("invalid devtools active port: %q", port)
+	}
+
+	return port, strings.TrimSpace(lines[1]), nil
+}
+
+// removeDevToolsActivePort deletes a stale DevToolsActivePort file left by a previous run.
+func removeDevToolsActivePort(dir string) error {
+	err := os.Remove(filepath.Join(dir, devToolsActivePortFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// watchDevToolsActivePort polls dir until the DevToolsActivePort file can be read,
+// sending the port on the returned channel. It gives up silently when ctx is done.
+func watchDevToolsActivePort(ctx context.Context, dir string) <-chan string {
+	portChan := make(chan string, 1)
+
+	go func() {
+		ticker := time.NewTicker(50 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			if port, _, err := readDevToolsActivePort(dir); err == nil {
+				portChan <- port
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return portChan
+}
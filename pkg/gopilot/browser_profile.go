@@ -0,0 +1,96 @@
+package gopilot
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// prepareDataDir resolves the user data dir for a new browser process based on the open input.
+// It returns the directory path and whether it should be removed when the browser closes.
+func prepareDataDir(in *BrowserOpenInput) (string, bool, error) {
+	if in.UserDataDir != "" && in.ProfileTemplate != "" {
+		return "", false, errors.New("UserDataDir and ProfileTemplate are mutually exclusive")
+	}
+
+	// existing (or to be created) profile owned by the caller, never removed
+	if in.UserDataDir != "" {
+		if err := os.MkdirAll(in.UserDataDir, 0o700); err != nil {
+			return "", false, err
+		}
+		return in.UserDataDir, false, nil
+	}
+
+	tempDir, err := os.MkdirTemp("", "gopilot")
+	if err != nil {
+		return "", false, err
+	}
+
+	if in.ProfileTemplate != "" {
+		if err = copyProfile(in.ProfileTemplate, tempDir); err != nil {
+			_ = os.RemoveAll(tempDir)
+			return "", false, err
+		}
+	}
+
+	return tempDir, !in.KeepUserDataDir, nil
+}
+
+// copyProfile clones the template profile at src into dst.
+// Lock files, sockets and other symlinks of a running browser are skipped.
+func copyProfile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	} else if !info.IsDir() {
+		return errors.New("profile template is not a directory")
+	}
+
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o700)
+		}
+
+		if !d.Type().IsRegular() ||
+			strings.HasPrefix(d.Name(), "Singleton") ||
+			d.Name() == devToolsActivePortFile {
+			return nil
+		}
+
+		return copyFile(path, target)
+	})
+}
+
+// copyFile copies a single regular file from src to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,320 @@
+package gopilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/mafredri/cdp/devtool"
+	"github.com/mafredri/cdp/protocol/target"
+	"github.com/mafredri/cdp/rpcc"
+)
+
+// sessionDetachTimeout bounds the detachFromTarget call made when a session conn is closed.
+const sessionDetachTimeout = 5 * time.Second
+
+// flatCodec is the rpcc.Codec of the browser target connection when
+// BrowserConfig.FlattenSessions is enabled. Messages carrying a sessionId
+// are routed to the matching flatSession instead of the browser client.
+type flatCodec struct {
+	rw  io.ReadWriter
+	dec *json.Decoder
+	wmu sync.Mutex // serializes writes of the browser client and its sessions
+
+	mux      sync.Mutex
+	sessions map[target.SessionID]*flatSession
+	closed   bool
+}
+
+func newFlatCodec(rw io.ReadWriter) *flatCodec {
+	return &flatCodec{
+		rw:       rw,
+		dec:      json.NewDecoder(rw),
+		sessions: map[target.SessionID]*flatSession{},
+	}
+}
+
+// write sends a single message over the shared websocket.
+func (c *flatCodec) write(data []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	_, err := c.rw.Write(data)
+	return err
+}
+
+// WriteRequest implements rpcc.Codec for the browser client.
+func (c *flatCodec) WriteRequest(r *rpcc.Request) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return c.write(data)
+}
+
+// ReadResponse implements rpcc.Codec for the browser client, dispatching
+// session messages to their session until a browser message is read.
+func (c *flatCodec) ReadResponse(r *rpcc.Response) error {
+	for {
+		var msg json.RawMessage
+		if err := c.dec.Decode(&msg); err != nil {
+			c.closeSessions()
+			return err
+		}
+
+		var envelope struct {
+			SessionID target.SessionID `json:"sessionId"`
+		}
+		if err := json.Unmarshal(msg, &envelope); err != nil {
+			return err
+		}
+
+		if envelope.SessionID == "" {
+			return json.Unmarshal(msg, r)
+		}
+
+		c.mux.Lock()
+		s, ok := c.sessions[envelope.SessionID]
+		c.mux.Unlock()
+
+		if ok {
+			s.deliver(msg)
+		}
+	}
+}
+
+// register starts routing messages of the session id to s.
+func (c *flatCodec) register(s *flatSession) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.closed {
+		return ErrBrowserClosed
+	}
+	c.sessions[s.id] = s
+	return nil
+}
+
+// unregister stops routing messages to the session id.
+func (c *flatCodec) unregister(id target.SessionID) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	delete(c.sessions, id)
+}
+
+// closeSessions ends every session once the browser connection is gone.
+func (c *flatCodec) closeSessions() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.closed = true
+	for id, s := range c.sessions {
+		s.end()
+		delete(c.sessions, id)
+	}
+}
+
+// flatSession is the rpcc.Codec of a target connection multiplexed
+// over the browser websocket with Target.attachToTarget{flatten: true}.
+type flatSession struct {
+	id    target.SessionID
+	codec *flatCodec
+
+	// queue holds the routed messages not read yet, it is unbounded so
+	// a slow session never blocks the reader of the shared websocket.
+	queueMu sync.Mutex
+	queue   [][]byte
+	ready   chan struct{}
+
+	done   chan struct{}
+	doneMu sync.Once
+}
+
+func newFlatSession(id target.SessionID, codec *flatCodec) *flatSession {
+	return &flatSession{
+		id:    id,
+		codec: codec,
+		ready: make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+}
+
+// WriteRequest implements rpcc.Codec, tagging the request with the session id.
+func (s *flatSession) WriteRequest(r *rpcc.Request) error {
+	data, err := json.Marshal(&struct {
+		ID        uint64           `json:"id"`
+		Method    string           `json:"method"`
+		Args      interface{}      `json:"params,omitempty"`
+		SessionID target.SessionID `json:"sessionId"`
+	}{
+		ID:        r.ID,
+		Method:    r.Method,
+		Args:      r.Args,
+		SessionID: s.id,
+	})
+	if err != nil {
+		return err
+	}
+	return s.codec.write(data)
+}
+
+// ReadResponse implements rpcc.Codec, reading messages routed by the browser codec.
+func (s *flatSession) ReadResponse(r *rpcc.Response) error {
+	for {
+		if msg, ok := s.next(); ok {
+			return json.Unmarshal(msg, r)
+		}
+
+		select {
+		case <-s.ready:
+		case <-s.done:
+			return io.EOF
+		}
+	}
+}
+
+// next pops the oldest queued message.
+func (s *flatSession) next() ([]byte, bool) {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	if len(s.queue) == 0 {
+		return nil, false
+	}
+	msg := s.queue[0]
+	s.queue[0] = nil
+	s.queue = s.queue[1:]
+	return msg, true
+}
+
+// deliver queues msg for the session without blocking the caller.
+func (s *flatSession) deliver(msg []byte) {
+	s.queueMu.Lock()
+	s.queue = append(s.queue, msg)
+	s.queueMu.Unlock()
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (s *flatSession) end() {
+	s.doneMu.Do(func() { close(s.done) })
+}
+
+// sessionConn is the io.ReadWriteCloser handed to rpcc for a flat session,
+// all traffic goes through the session codec so only Close is meaningful.
+type sessionConn struct {
+	close func() error
+}
+
+func (c *sessionConn) Read([]byte) (int, error)  { return 0, errors.New("not allowed") }
+func (c *sessionConn) Write([]byte) (int, error) { return 0, errors.New("not allowed") }
+func (c *sessionConn) Close() error              { return c.close() }
+
+// dialSession attaches to the target in flat mode and returns a connection
+// multiplexed over the browser websocket.
+func (b *browser) dialSession(ctx context.Context, id target.ID) (*rpcc.Conn, error) {
+	client, err := b.browserClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b.connMux.Lock()
+	codec := b.flat
+	b.connMux.Unlock()
+	if codec == nil {
+		return nil, errors.New("browser connection is not in flatten mode")
+	}
+
+	rp, err := client.Target.AttachToTarget(ctx, target.NewAttachToTargetArgs(id).SetFlatten(true))
+	if err != nil {
+		return nil, err
+	}
+	b.logger.Debug("attached to target", "target_id", id, "session_id", rp.SessionID)
+
+	s := newFlatSession(rp.SessionID, codec)
+
+	// rpcc closes the conn while holding its lock, so the session stops
+	// routing and reading before the detach round trip is made.
+	detach := func() error {
+		codec.unregister(s.id)
+		s.end()
+
+		ctx, cancel := context.WithTimeout(context.Background(), sessionDetachTimeout)
+		defer cancel()
+
+		return client.Target.DetachFromTarget(ctx, target.NewDetachFromTargetArgs().SetSessionID(s.id))
+	}
+
+	if err = codec.register(s); err != nil {
+		_ = detach()
+		return nil, err
+	}
+
+	conn, err := rpcc.DialContext(ctx, "",
+		rpcc.WithDialer(func(context.Context, string) (io.ReadWriteCloser, error) {
+			return &sessionConn{close: detach}, nil
+		}),
+		rpcc.WithCodec(func(io.ReadWriter) rpcc.Codec { return s }),
+	)
+	if err != nil {
+		// leave neither the session routed nor the target attached
+		if dErr := detach(); dErr != nil {
+			b.logger.Debug("unable to detach from target", "target_id", id, "session_id", s.id, "error", dErr)
+		}
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// newBrowserConn dials the browser target websocket, multiplexing target
+// sessions over it when BrowserConfig.FlattenSessions is enabled.
+func (b *browser) newBrowserConn(ctx context.Context, url string) (*rpcc.Conn, *flatCodec, error) {
+	if !b.config.FlattenSessions {
+		conn, err := rpcc.DialContext(ctx, url)
+		return conn, nil, err
+	}
+
+	var codec *flatCodec
+	conn, err := rpcc.DialContext(ctx, url, rpcc.WithCodec(func(rw io.ReadWriter) rpcc.Codec {
+		codec = newFlatCodec(rw)
+		return codec
+	}))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return conn, codec, nil
+}
+
+// newPageTarget returns a page target through the Target domain, creating
+// a new tab when newTab is set or no page exists yet.
+func (b *browser) newPageTarget(ctx context.Context, newTab bool) (*devtool.Target, error) {
+	if !newTab {
+		targets, err := b.listDefaultPageTargets(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(targets) > 0 {
+			return targets[0], nil
+		}
+	}
+
+	client, err := b.browserClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rp, err := client.Target.CreateTarget(ctx, target.NewCreateTargetArgs("about:blank"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &devtool.Target{ID: string(rp.TargetID), Type: devtool.Page, URL: "about:blank"}, nil
+}
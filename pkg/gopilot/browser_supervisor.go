@@ -0,0 +1,159 @@
+package gopilot
+
+import (
+	"context"
+	"fmt"
+)
+
+// BrowserCrashEvent describes an unexpected exit of the browser process.
+type BrowserCrashEvent struct {
+	Err        error // Err matches ErrBrowserExited and wraps the process exit error.
+	Restarted  bool  // Restarted reports whether the browser was relaunched successfully.
+	Restarts   int   // Restarts is the number of restarts performed so far.
+	RestartErr error // RestartErr holds the relaunch failure, if any.
+
+	// Output holds the last lines written by the crashed browser.
+	Output []string
+}
+
+// Done returns a channel that is closed once the browser is no longer usable.
+func (b *browser) Done() <-chan struct{} {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	return b.done
+}
+
+// Err returns the reason Done was closed, or nil while the browser is running.
+func (b *browser) Err() error {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	return b.err
+}
+
+// finish records err and closes the done channel, only the first call has effect.
+func (b *browser) finish(err error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if b.err != nil {
+		return
+	}
+	b.err = err
+	close(b.done)
+}
+
+// supervise waits for the browser process to exit and, unless it was closed
+// on purpose, reports the crash and relaunches it when configured to.
+func (b *browser) supervise(exited <-chan struct{}) {
+	<-exited
+
+	b.mux.Lock()
+	if b.closing {
+		b.mux.Unlock()
+		return
+	}
+
+	crashErr := ErrBrowserExited
+	if b.exitErr != nil {
+		crashErr = fmt.Errorf("%w: %w", ErrBrowserExited, b.exitErr)
+	}
+	b.logger.Warn("browser exited unexpectedly", "error", b.exitErr)
+
+	// children of the crashed browser must not outlive it
+	if err := killProcess(b.instance); err != nil {
+		b.logger.Debug("unable to kill browser process group", "error", err)
+	}
+
+	// pages of the dead process can no longer be used
+	for _, p := range b.pages {
+		p := p.(*page)
+		p.mux.Lock()
+		p.closed = true
+		p.mux.Unlock()
+		_ = p.conn.Close()
+	}
+	b.pages = nil
+	contexts := b.contexts
+	b.contexts = nil
+	b.devtool = nil
+
+	b.closeClient()
+
+	restart := b.config.RestartOnCrash &&
+		(b.config.MaxRestarts <= 0 || b.restarts < b.config.MaxRestarts)
+	b.mux.Unlock()
+
+	for _, c := range contexts {
+		c.mux.Lock()
+		c.closed = true
+		c.mux.Unlock()
+	}
+
+	ev := &BrowserCrashEvent{Err: crashErr, Output: b.outputTail(outputTailSize)}
+	if restart {
+		ev.RestartErr = b.restart()
+		ev.Restarted = ev.RestartErr == nil
+	}
+
+	b.mux.RLock()
+	ev.Restarts = b.restarts
+	b.mux.RUnlock()
+
+	if !ev.Restarted {
+		b.finish(crashErr)
+		b.stopDisplay()
+	}
+
+	if b.config.OnCrash != nil {
+		b.config.OnCrash(ev)
+	}
+}
+
+// restart relaunches the browser process with the same configuration and data dir.
+func (b *browser) restart() error {
+	b.procMux.Lock()
+	defer b.procMux.Unlock()
+
+	// Close won the race, nothing to restart
+	b.mux.RLock()
+	closing := b.closing
+	b.mux.RUnlock()
+	if closing {
+		return ErrBrowserClosed
+	}
+
+	b.mux.Lock()
+	b.restarts++
+	b.mux.Unlock()
+
+	b.logger.Info("restarting browser", "restarts", b.restarts)
+
+	if err := b.start(context.Background(), b.execPath, b.launchTimeout); err != nil {
+		return err
+	}
+
+	go b.supervise(b.exited)
+
+	// resume target discovery for the registered page listeners
+	b.mux.RLock()
+	listening := len(b.pageListeners) > 0
+	b.mux.RUnlock()
+	if listening {
+		if err := b.watchTargets(context.Background()); err != nil {
+			b.logger.Warn("unable to watch targets after restart", "error", err)
+		}
+	}
+
+	// restore the download behavior of the default context
+	b.mux.Lock()
+	downloadDir := b.downloadDir
+	b.downloadDirs = nil
+	b.mux.Unlock()
+	if downloadDir != "" {
+		if _, err := b.setDownloadBehavior(context.Background(), downloadDir, nil); err != nil {
+			b.logger.Warn("unable to enable downloads after restart", "error", err)
+		}
+	}
+
+	return nil
+}
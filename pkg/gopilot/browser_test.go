@@ -0,0 +1,88 @@
+package gopilot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestResolveDevtoolHost(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		want     string
+	}{
+		{name: "ip address", endpoint: "http://10.0.0.2:9222", want: "http://10.0.0.2:9222"},
+		{name: "ipv6 address", endpoint: "http://[::1]:9222", want: "http://[::1]:9222"},
+		{name: "localhost", endpoint: "http://localhost:9222", want: "http://localhost:9222"},
+		{name: "https keeps its name", endpoint: "https://chrome.example.com", want: "https://chrome.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveDevtoolHost(context.Background(), tt.endpoint)
+			if err != nil {
+				t.Fatalf("resolveDevtoolHost() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveDevtoolHost() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBrowserOpenRemoteHostname connects by hostname to an endpoint that,
+// like Chrome, rejects requests whose Host header is not an IP address or localhost.
+func TestBrowserOpenRemoteHostname(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skip(err)
+	}
+	addrs, err := net.LookupHost(hostname)
+	if err != nil || !net.ParseIP(addrs[0]).IsLoopback() {
+		t.Skipf("hostname %q does not resolve to a loopback address", hostname)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.Host)
+		if net.ParseIP(host) == nil && host != "localhost" {
+			http.Error(w, "Host header is specified and is not an IP address or localhost.", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"Browser": "Chrome/126.0.0.0", "webSocketDebuggerUrl": "ws://%s/devtools/browser/id"}`, r.Host)
+	}))
+	defer srv.Close()
+
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+
+	for _, remote := range []string{
+		net.JoinHostPort(hostname, port),
+		fmt.Sprintf("ws://%s/devtools/browser/id", net.JoinHostPort(hostname, port)),
+	} {
+		t.Run(remote, func(t *testing.T) {
+			cfg := NewBrowserConfig()
+			cfg.RemoteURL = remote
+			b := NewBrowser(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			if err := b.Open(context.Background(), nil); err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			defer b.Close(context.Background())
+
+			v, err := b.GetDevToolClient().Version(context.Background())
+			if err != nil {
+				t.Fatalf("Version() error = %v", err)
+			}
+			if strings.Contains(v.WebSocketDebuggerURL, hostname+":") {
+				t.Errorf("WebSocketDebuggerURL = %q, want the resolved address", v.WebSocketDebuggerURL)
+			}
+		})
+	}
+}
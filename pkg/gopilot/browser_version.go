@@ -0,0 +1,33 @@
+package gopilot
+
+import "context"
+
+// BrowserVersion holds the version information reported by the browser.
+type BrowserVersion struct {
+	Product         string // Product name and version, e.g. "HeadlessChrome/120.0.6099.109".
+	Revision        string // Revision is the product revision.
+	ProtocolVersion string // ProtocolVersion is the DevTools protocol version.
+	UserAgent       string // UserAgent is the default User-Agent.
+	V8              string // V8 is the JavaScript engine version.
+}
+
+// Version retrieves the version information of the running browser.
+func (b *browser) Version(ctx context.Context) (*BrowserVersion, error) {
+	client, err := b.browserClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rp, err := client.Browser.GetVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BrowserVersion{
+		Product:         rp.Product,
+		Revision:        rp.Revision,
+		ProtocolVersion: rp.ProtocolVersion,
+		UserAgent:       rp.UserAgent,
+		V8:              rp.JSVersion,
+	}, nil
+}
@@ -1,6 +1,28 @@
 package gopilot
 
-import "os"
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultLaunchTimeout is used when BrowserConfig.LaunchTimeout is not set.
+	defaultLaunchTimeout = 5 * time.Second
+
+	// defaultCloseGracePeriod is used when BrowserConfig.CloseGracePeriod is not set.
+	defaultCloseGracePeriod = 5 * time.Second
+
+	// outputTailSize is the number of output lines kept for launch and crash diagnostics.
+	outputTailSize = 20
+
+	// defaultOutputBufferSize is used when BrowserConfig.OutputBufferSize is not set.
+	defaultOutputBufferSize = 200
+)
 
 // BrowserConfig holds configuration settings for launching a browser instance.
 type BrowserConfig struct {
@@ -8,18 +30,80 @@ type BrowserConfig struct {
 	Path string
 
 	// DebugPort specifies the port for debugging connections.
+	// "0" or an empty value lets the browser pick a free port.
 	DebugPort string
 
-	// Args contains additional command-line arguments to pass when launching the browser.
+	// RemoteURL is the DevTools endpoint of an already running browser,
+	// either "host:port" or a "ws://host:port/devtools/browser/<id>" URL.
+	// When set, Open attaches to it instead of launching Path and Close only detaches.
+	RemoteURL string
+
+	// Flags holds the browser command-line switches, rendered at Open.
+	Flags *Flags
+
+	// Args contains additional raw command-line arguments to pass when launching
+	// the browser. They are merged over Flags, so a switch set in both takes the Args value.
 	Args []string
 
-	// Envs holds any environment variables to set for the browser process.
+	// Envs holds the environment of the browser process. When nil the browser
+	// inherits the environment of this process, otherwise Envs replaces it.
 	Envs []string
+
+	// Output, when set, receives every line the browser process writes to stdout and stderr.
+	Output io.Writer
+
+	// OutputBufferSize is the number of recent output lines kept for Browser.Output.
+	// Defaults to 200 when zero.
+	OutputBufferSize int
+
+	// VirtualDisplay starts a private Xvfb server for a headful browser when
+	// DISPLAY is unset, its lifetime is tied to Open and Close. Ignored in headless mode.
+	VirtualDisplay *VirtualDisplayConfig
+
+	// Proxy routes the browser traffic through a proxy server. Credentials,
+	// if any, are answered automatically on every page through Fetch.continueWithAuth.
+	Proxy *ProxyConfig
+
+	// Extensions lists the directories of unpacked extensions to load.
+	// Every other extension is disabled. Extensions require a headful browser
+	// or the new headless mode, they are not supported by chrome-headless-shell.
+	Extensions []string
+
+	// FlattenSessions makes pages share a single browser websocket connection,
+	// attaching to targets with Target.attachToTarget{flatten: true} instead
+	// of dialing one websocket per page. Targets are discovered through the
+	// Target domain, so those without an HTTP listing are visible as well.
+	FlattenSessions bool
+
+	// LaunchTimeout is how long Open waits for the DevTools endpoint on each attempt.
+	// Defaults to 5 seconds when zero.
+	LaunchTimeout time.Duration
+
+	// LaunchRetries is the number of additional launch attempts after a timeout or early exit.
+	LaunchRetries int
+
+	// CloseGracePeriod is how long Close waits after SIGTERM before killing
+	// the browser process group. Defaults to 5 seconds when zero.
+	CloseGracePeriod time.Duration
+
+	// RestartOnCrash relaunches the browser with the same configuration when
+	// the process exits without Close being called. Pages of the crashed
+	// process are closed and must be created again.
+	RestartOnCrash bool
+
+	// MaxRestarts limits the automatic restarts, zero means unlimited.
+	MaxRestarts int
+
+	// OnCrash is called after the browser process exits unexpectedly,
+	// once the restart (if enabled) has been attempted.
+	OnCrash func(ev *BrowserCrashEvent)
 }
 
 // NewBrowserConfig creates a new BrowserConfig with default settings.
-// The default Path is "google-chrome-stable" and the default DebugPort is "9222".
-// It includes several default command-line arguments for browser startup.
+// The default Path is $GOPILOT_CHROME_EXECUTABLE or "google-chrome-stable",
+// Open falls back to FindExecutable when it cannot be resolved. The default DebugPort is "0",
+// letting the browser pick a free port so multiple instances can run side by side.
+// Flags holds several default switches for browser startup.
 func NewBrowserConfig() *BrowserConfig {
 	execPath := os.Getenv("GOPILOT_CHROME_EXECUTABLE")
 	if execPath == "" {
@@ -27,9 +111,11 @@ func NewBrowserConfig() *BrowserConfig {
 	}
 
 	c := &BrowserConfig{
-		Path:      execPath, // can be changed by user
-		DebugPort: "9222",
-		Args: []string{
+		Path:             execPath, // can be changed by user
+		DebugPort:        "0",
+		LaunchTimeout:    defaultLaunchTimeout,
+		CloseGracePeriod: defaultCloseGracePeriod,
+		Flags: ParseFlags([]string{
 			"--remote-allow-origins=*",
 			"--no-first-run",
 			"--no-service-autorun",
@@ -43,7 +129,7 @@ func NewBrowserConfig() *BrowserConfig {
 			"--disable-session-crashed-bubble",
 			"--disable-search-engine-choice-screen",
 			"--window-size=1920,1080",
-		},
+		}),
 	}
 	return c
 }
@@ -54,7 +140,71 @@ func (c *BrowserConfig) AddArgument(arg string) {
 	c.Args = append(c.Args, arg)
 }
 
-// EnableHeadless will make the browser to start as headless
+// EnableHeadless will make the browser to start as headless, calling it again has no effect.
 func (c *BrowserConfig) EnableHeadless() {
-	c.AddArgument("--headless=new")
+	if c.Flags == nil {
+		c.Flags = NewFlags()
+	}
+	c.Flags.SetHeadless(true)
+}
+
+// launchFlags returns Flags merged with Args, the switches the browser is launched with.
+func (c *BrowserConfig) launchFlags() *Flags {
+	return NewFlags().Merge(c.Flags).Merge(ParseFlags(c.Args))
+}
+
+// headless reports whether the browser is launched in a headless mode and its value.
+func (c *BrowserConfig) headless() (string, bool) {
+	return c.launchFlags().Get("headless")
+}
+
+// ProxyConfig describes a proxy server and its optional credentials.
+// The tags name its keys in the files read by LoadBrowserConfig.
+type ProxyConfig struct {
+	// Server is the proxy address, e.g. "http://host:3128" or "socks5://host:1080".
+	Server string `json:"server" yaml:"server"`
+
+	// Bypass lists the hosts that are reached without the proxy, e.g. "localhost" or "*.internal".
+	Bypass []string `json:"bypass" yaml:"bypass"`
+
+	// Username and Password answer the proxy authentication challenge on the
+	// pages gopilot is attached to, see Browser.OnPage for popups.
+	// Chrome does not support authentication for SOCKS proxies.
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+}
+
+// bypassList renders Bypass in the format of --proxy-bypass-list.
+func (p *ProxyConfig) bypassList() string {
+	return strings.Join(p.Bypass, ";")
+}
+
+// hasCredentials reports whether the proxy requires authentication.
+func (p *ProxyConfig) hasCredentials() bool {
+	return p != nil && (p.Username != "" || p.Password != "")
+}
+
+// validateExtensions checks the configured extensions exist and that the
+// browser mode is able to load them.
+func (c *BrowserConfig) validateExtensions(execPath string) error {
+	if len(c.Extensions) == 0 {
+		return nil
+	}
+
+	for _, dir := range c.Extensions {
+		if _, err := os.Stat(filepath.Join(dir, "manifest.json")); err != nil {
+			return fmt.Errorf("invalid unpacked extension %s: %w", dir, err)
+		}
+	}
+
+	if strings.Contains(filepath.Base(execPath), "headless") {
+		return errors.New("extensions are not supported by chrome-headless-shell")
+	}
+
+	// a bare --headless is the new headless mode in current Chrome
+	if mode, ok := c.headless(); ok && mode == "old" {
+		return errors.New("extensions are not supported by --headless=old")
+	}
+
+	return nil
 }
@@ -0,0 +1,377 @@
+package gopilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix prefixes every environment variable read by LoadBrowserConfig.
+const envPrefix = "GOPILOT_"
+
+// browserEnvPrefix prefixes the environment variables passed to the browser
+// process, e.g. GOPILOT_BROWSER_ENV_TZ=UTC adds TZ=UTC to the environment
+// the browser inherits from this process.
+const browserEnvPrefix = envPrefix + "BROWSER_ENV_"
+
+// ConfigError describes an invalid configuration value.
+type ConfigError struct {
+	Source string // Source is the config file path or the environment variable name.
+	Field  string // Field is the config key, empty for errors about the whole source.
+	Err    error  // Err is the reason the value is invalid.
+}
+
+// Error implements the error interface.
+func (e *ConfigError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("gopilot config %s: %s", e.Source, e.Err)
+	}
+	return fmt.Sprintf("gopilot config %s: %s: %s", e.Source, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// configFile is the JSON/YAML representation of a BrowserConfig.
+// Pointers tell unset values apart from zero ones.
+type configFile struct {
+	Path             string       `json:"path" yaml:"path"`
+	DebugPort        string       `json:"debug_port" yaml:"debug_port"`
+	RemoteURL        string       `json:"remote_url" yaml:"remote_url"`
+	Headless         *bool        `json:"headless" yaml:"headless"`
+	Presets          []string     `json:"presets" yaml:"presets"`
+	Args             []string     `json:"args" yaml:"args"`
+	Envs             []string     `json:"envs" yaml:"envs"`
+	Proxy            *ProxyConfig `json:"proxy" yaml:"proxy"`
+	Extensions       []string     `json:"extensions" yaml:"extensions"`
+	FlattenSessions  *bool        `json:"flatten_sessions" yaml:"flatten_sessions"`
+	LaunchTimeout    string       `json:"launch_timeout" yaml:"launch_timeout"`
+	LaunchRetries    *int         `json:"launch_retries" yaml:"launch_retries"`
+	CloseGracePeriod string       `json:"close_grace_period" yaml:"close_grace_period"`
+	RestartOnCrash   *bool        `json:"restart_on_crash" yaml:"restart_on_crash"`
+	MaxRestarts      *int         `json:"max_restarts" yaml:"max_restarts"`
+}
+
+// LoadBrowserConfig builds a BrowserConfig from, in increasing precedence:
+//   - the defaults of NewBrowserConfig,
+//   - the JSON or YAML file at path, or at GOPILOT_CONFIG when path is empty,
+//   - the GOPILOT_* environment variables.
+//
+// A later source replaces the values set by an earlier one, lists included,
+// except browser environment variables which are merged by name.
+// The recognized variables are GOPILOT_CHROME_EXECUTABLE, GOPILOT_DEBUG_PORT,
+// GOPILOT_REMOTE_URL, GOPILOT_HEADLESS, GOPILOT_PRESETS, GOPILOT_ARGS
+// (space separated), GOPILOT_PROXY_SERVER, GOPILOT_PROXY_BYPASS,
+// GOPILOT_PROXY_USERNAME, GOPILOT_PROXY_PASSWORD, GOPILOT_EXTENSIONS,
+// GOPILOT_FLATTEN_SESSIONS, GOPILOT_LAUNCH_TIMEOUT, GOPILOT_LAUNCH_RETRIES,
+// GOPILOT_CLOSE_GRACE_PERIOD, GOPILOT_RESTART_ON_CRASH, GOPILOT_MAX_RESTARTS
+// and GOPILOT_BROWSER_ENV_<NAME>. Comma separates list values.
+//
+// Every invalid value is reported as a *ConfigError, joined with errors.Join.
+func LoadBrowserConfig(path string) (*BrowserConfig, error) {
+	c := NewBrowserConfig()
+
+	if path == "" {
+		path = os.Getenv(envPrefix + "CONFIG")
+	}
+
+	var errs []error
+	if path != "" {
+		f, err := readConfigFile(path)
+		if err != nil {
+			return nil, &ConfigError{Source: path, Err: err}
+		}
+		errs = append(errs, f.apply(c, path)...)
+	}
+
+	errs = append(errs, applyEnv(c)...)
+	errs = append(errs, c.validate()...)
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return c, nil
+}
+
+// readConfigFile decodes the file at path according to its extension,
+// rejecting unknown keys.
+func readConfigFile(path string) (*configFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &configFile{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(f)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(f)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q, use .json, .yaml or .yml", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// apply sets the values of the file on c, reporting invalid ones.
+func (f *configFile) apply(c *BrowserConfig, source string) []error {
+	var errs []error
+	fail := func(field string, err error) {
+		errs = append(errs, &ConfigError{Source: source, Field: field, Err: err})
+	}
+
+	if f.Path != "" {
+		c.Path = f.Path
+	}
+	if f.DebugPort != "" {
+		c.DebugPort = f.DebugPort
+	}
+	if f.RemoteURL != "" {
+		c.RemoteURL = f.RemoteURL
+	}
+	for _, p := range f.Presets {
+		if err := c.Flags.ApplyPreset(FlagPreset(p)); err != nil {
+			fail("presets", err)
+		}
+	}
+	if f.Headless != nil {
+		c.Flags.SetHeadless(*f.Headless)
+	}
+	if f.Args != nil {
+		c.Args = f.Args
+	}
+	for _, env := range f.Envs {
+		if !strings.Contains(env, "=") {
+			fail("envs", fmt.Errorf("%q is not in KEY=VALUE form", env))
+			continue
+		}
+		c.setBrowserEnv(env)
+	}
+	if f.Proxy != nil {
+		c.Proxy = f.Proxy
+	}
+	if f.Extensions != nil {
+		c.Extensions = f.Extensions
+	}
+	if f.FlattenSessions != nil {
+		c.FlattenSessions = *f.FlattenSessions
+	}
+	if f.LaunchTimeout != "" {
+		d, err := time.ParseDuration(f.LaunchTimeout)
+		if err != nil {
+			fail("launch_timeout", err)
+		}
+		c.LaunchTimeout = d
+	}
+	if f.LaunchRetries != nil {
+		c.LaunchRetries = *f.LaunchRetries
+	}
+	if f.CloseGracePeriod != "" {
+		d, err := time.ParseDuration(f.CloseGracePeriod)
+		if err != nil {
+			fail("close_grace_period", err)
+		}
+		c.CloseGracePeriod = d
+	}
+	if f.RestartOnCrash != nil {
+		c.RestartOnCrash = *f.RestartOnCrash
+	}
+	if f.MaxRestarts != nil {
+		c.MaxRestarts = *f.MaxRestarts
+	}
+
+	return errs
+}
+
+// applyEnv sets the values of the GOPILOT_* environment variables on c,
+// reporting invalid ones.
+func applyEnv(c *BrowserConfig) []error {
+	var errs []error
+	fail := func(name string, err error) {
+		errs = append(errs, &ConfigError{Source: "$" + envPrefix + name, Err: err})
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := os.LookupEnv(envPrefix + name)
+		return v, ok && v != ""
+	}
+	list := func(v string) []string {
+		var items []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	boolean := func(name string, set func(bool)) {
+		if v, ok := lookup(name); ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				fail(name, err)
+				return
+			}
+			set(b)
+		}
+	}
+	integer := func(name string, set func(int)) {
+		if v, ok := lookup(name); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				fail(name, err)
+				return
+			}
+			set(n)
+		}
+	}
+	duration := func(name string, set func(time.Duration)) {
+		if v, ok := lookup(name); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				fail(name, err)
+				return
+			}
+			set(d)
+		}
+	}
+
+	if v, ok := lookup("CHROME_EXECUTABLE"); ok {
+		c.Path = v
+	}
+	if v, ok := lookup("DEBUG_PORT"); ok {
+		c.DebugPort = v
+	}
+	if v, ok := lookup("REMOTE_URL"); ok {
+		c.RemoteURL = v
+	}
+	if v, ok := lookup("PRESETS"); ok {
+		for _, p := range list(v) {
+			if err := c.Flags.ApplyPreset(FlagPreset(p)); err != nil {
+				fail("PRESETS", err)
+			}
+		}
+	}
+	boolean("HEADLESS", func(b bool) { c.Flags.SetHeadless(b) })
+	if v, ok := lookup("ARGS"); ok {
+		c.Args = strings.Fields(v)
+	}
+	if v, ok := lookup("EXTENSIONS"); ok {
+		c.Extensions = list(v)
+	}
+
+	if v, ok := lookup("PROXY_SERVER"); ok {
+		c.Proxy = &ProxyConfig{Server: v}
+	}
+	proxy := func() *ProxyConfig {
+		if c.Proxy == nil {
+			c.Proxy = &ProxyConfig{}
+		}
+		return c.Proxy
+	}
+	if v, ok := lookup("PROXY_BYPASS"); ok {
+		proxy().Bypass = list(v)
+	}
+	if v, ok := lookup("PROXY_USERNAME"); ok {
+		proxy().Username = v
+	}
+	if v, ok := lookup("PROXY_PASSWORD"); ok {
+		proxy().Password = v
+	}
+
+	boolean("FLATTEN_SESSIONS", func(b bool) { c.FlattenSessions = b })
+	duration("LAUNCH_TIMEOUT", func(d time.Duration) { c.LaunchTimeout = d })
+	integer("LAUNCH_RETRIES", func(n int) { c.LaunchRetries = n })
+	duration("CLOSE_GRACE_PERIOD", func(d time.Duration) { c.CloseGracePeriod = d })
+	boolean("RESTART_ON_CRASH", func(b bool) { c.RestartOnCrash = b })
+	integer("MAX_RESTARTS", func(n int) { c.MaxRestarts = n })
+
+	for _, env := range os.Environ() {
+		if kv, ok := strings.CutPrefix(env, browserEnvPrefix); ok {
+			c.setBrowserEnv(kv)
+		}
+	}
+
+	return errs
+}
+
+// validate reports the values that are well formed but unusable.
+func (c *BrowserConfig) validate() []error {
+	var errs []error
+	fail := func(field string, err error) {
+		errs = append(errs, &ConfigError{Source: "validation", Field: field, Err: err})
+	}
+
+	if c.RemoteURL == "" && c.Path == "" {
+		fail("path", errors.New("browser executable is required"))
+	}
+	if c.DebugPort != "" {
+		if port, err := strconv.Atoi(c.DebugPort); err != nil || port < 0 || port > 65535 {
+			fail("debug_port", fmt.Errorf("%q is not a port number", c.DebugPort))
+		}
+	}
+	if c.RemoteURL != "" {
+		if _, err := devtoolEndpoint(c.RemoteURL); err != nil {
+			fail("remote_url", err)
+		}
+	}
+	if p := c.Proxy; p != nil {
+		if p.Server == "" {
+			fail("proxy", errors.New("server is required"))
+		} else if _, err := url.Parse(p.Server); err != nil {
+			fail("proxy", err)
+		}
+	}
+	if c.LaunchTimeout < 0 {
+		fail("launch_timeout", errors.New("must not be negative"))
+	}
+	if c.LaunchRetries < 0 {
+		fail("launch_retries", errors.New("must not be negative"))
+	}
+	if c.CloseGracePeriod < 0 {
+		fail("close_grace_period", errors.New("must not be negative"))
+	}
+	if c.MaxRestarts < 0 {
+		fail("max_restarts", errors.New("must not be negative"))
+	}
+
+	return errs
+}
+
+// setBrowserEnv sets the KEY=VALUE entry kv in Envs. A nil Envs is seeded with
+// the environment of this process first, as a non-nil one replaces it entirely.
+func (c *BrowserConfig) setBrowserEnv(kv string) {
+	if c.Envs == nil {
+		c.Envs = os.Environ()
+	}
+	c.Envs = setEnv(c.Envs, kv)
+}
+
+// setEnv sets the KEY=VALUE entry kv in envs, replacing an entry with the same key.
+func setEnv(envs []string, kv string) []string {
+	key, _, _ := strings.Cut(kv, "=")
+	for i, env := range envs {
+		if k, _, _ := strings.Cut(env, "="); k == key {
+			envs[i] = kv
+			return envs
+		}
+	}
+	return append(envs, kv)
+}
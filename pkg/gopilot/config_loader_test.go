@@ -0,0 +1,381 @@
+package gopilot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearConfigEnv unsets the GOPILOT_* variables of the environment for the test.
+func clearConfigEnv(t *testing.T) {
+	t.Helper()
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if strings.HasPrefix(name, envPrefix) {
+			t.Setenv(name, "")
+			_ = os.Unsetenv(name)
+		}
+	}
+}
+
+// writeConfig writes a config file named name in a temporary directory.
+func writeConfig(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadBrowserConfigFile(t *testing.T) {
+	want := func(c *BrowserConfig) *BrowserConfig {
+		c.Path = "/usr/bin/chromium"
+		c.DebugPort = "9222"
+		c.Args = []string{"--lang=fr-FR"}
+		c.Envs = setEnv(os.Environ(), "TZ=UTC")
+		c.Proxy = &ProxyConfig{
+			Server:   "http://proxy:3128",
+			Bypass:   []string{"localhost", "*.internal"},
+			Username: "user",
+			Password: "secret",
+		}
+		c.Extensions = []string{"./ext"}
+		c.FlattenSessions = true
+		c.LaunchTimeout = 10 * time.Second
+		c.LaunchRetries = 2
+		c.CloseGracePeriod = time.Second
+		c.RestartOnCrash = true
+		c.MaxRestarts = 3
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml",
+			file: "browser.yaml",
+			content: `
+path: /usr/bin/chromium
+debug_port: "9222"
+headless: true
+args: ["--lang=fr-FR"]
+envs: ["TZ=UTC"]
+proxy:
+  server: http://proxy:3128
+  bypass: [localhost, "*.internal"]
+  username: user
+  password: secret
+extensions: [./ext]
+flatten_sessions: true
+launch_timeout: 10s
+launch_retries: 2
+close_grace_period: 1s
+restart_on_crash: true
+max_restarts: 3
+`,
+		},
+		{
+			name: "json",
+			file: "browser.json",
+			content: `{
+	"path": "/usr/bin/chromium",
+	"debug_port": "9222",
+	"headless": true,
+	"args": ["--lang=fr-FR"],
+	"envs": ["TZ=UTC"],
+	"proxy": {
+		"server": "http://proxy:3128",
+		"bypass": ["localhost", "*.internal"],
+		"username": "user",
+		"password": "secret"
+	},
+	"extensions": ["./ext"],
+	"flatten_sessions": true,
+	"launch_timeout": "10s",
+	"launch_retries": 2,
+	"close_grace_period": "1s",
+	"restart_on_crash": true,
+	"max_restarts": 3
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+
+			got, err := LoadBrowserConfig(writeConfig(t, tt.file, tt.content))
+			if err != nil {
+				t.Fatalf("LoadBrowserConfig() error = %v", err)
+			}
+
+			if v, ok := got.Flags.Get("headless"); !ok || v != "new" {
+				t.Errorf("headless = %q, %v, want new", v, ok)
+			}
+
+			expected := want(NewBrowserConfig())
+			expected.Flags = got.Flags
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("LoadBrowserConfig() = %+v, want %+v", got, expected)
+			}
+		})
+	}
+}
+
+func TestLoadBrowserConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		env   map[string]string
+		check func(t *testing.T, c *BrowserConfig)
+	}{
+		{
+			name: "defaults without file or environment",
+			check: func(t *testing.T, c *BrowserConfig) {
+				if c.LaunchTimeout != defaultLaunchTimeout || c.DebugPort != "0" || c.Proxy != nil {
+					t.Errorf("got timeout %s, port %q, proxy %+v, want the defaults", c.LaunchTimeout, c.DebugPort, c.Proxy)
+				}
+			},
+		},
+		{
+			name: "environment overrides the file",
+			file: "launch_timeout: 3s\nlaunch_retries: 1\nargs: [--a, --b]\n",
+			env:  map[string]string{"LAUNCH_TIMEOUT": "7s", "ARGS": "--c  --d"},
+			check: func(t *testing.T, c *BrowserConfig) {
+				if c.LaunchTimeout != 7*time.Second {
+					t.Errorf("LaunchTimeout = %s, want 7s", c.LaunchTimeout)
+				}
+				if c.LaunchRetries != 1 {
+					t.Errorf("LaunchRetries = %d, want 1 from the file", c.LaunchRetries)
+				}
+				if want := []string{"--c", "--d"}; !slices.Equal(c.Args, want) {
+					t.Errorf("Args = %q, want %q", c.Args, want)
+				}
+			},
+		},
+		{
+			name: "headless applies after presets",
+			file: "presets: [ci]\nheadless: false\n",
+			check: func(t *testing.T, c *BrowserConfig) {
+				if c.Flags.Has("headless") {
+					t.Error("headless is set, want it removed by headless: false")
+				}
+				if !c.Flags.Has("mute-audio") {
+					t.Error("mute-audio is missing, want it from the ci preset")
+				}
+			},
+		},
+		{
+			name: "environment headless overrides the file",
+			file: "headless: false\n",
+			env:  map[string]string{"HEADLESS": "true"},
+			check: func(t *testing.T, c *BrowserConfig) {
+				if !c.Flags.Has("headless") {
+					t.Error("headless is missing, want it from the environment")
+				}
+			},
+		},
+		{
+			name: "proxy credentials from the environment complete the file proxy",
+			file: "proxy:\n  server: http://proxy:3128\n  bypass: [localhost]\n",
+			env:  map[string]string{"PROXY_USERNAME": "user", "PROXY_PASSWORD": "secret"},
+			check: func(t *testing.T, c *BrowserConfig) {
+				want := &ProxyConfig{Server: "http://proxy:3128", Bypass: []string{"localhost"}, Username: "user", Password: "secret"}
+				if !reflect.DeepEqual(c.Proxy, want) {
+					t.Errorf("Proxy = %+v, want %+v", c.Proxy, want)
+				}
+			},
+		},
+		{
+			name: "proxy server from the environment replaces the file proxy",
+			file: "proxy:\n  server: http://proxy:3128\n  bypass: [localhost]\n",
+			env:  map[string]string{"PROXY_SERVER": "socks5://other:1080"},
+			check: func(t *testing.T, c *BrowserConfig) {
+				want := &ProxyConfig{Server: "socks5://other:1080"}
+				if !reflect.DeepEqual(c.Proxy, want) {
+					t.Errorf("Proxy = %+v, want %+v", c.Proxy, want)
+				}
+			},
+		},
+		{
+			name: "browser environment variables are merged by key",
+			file: "envs: [TZ=UTC, LANG=C]\n",
+			env:  map[string]string{"BROWSER_ENV_TZ": "Europe/Paris", "BROWSER_ENV_HOME": "/tmp"},
+			check: func(t *testing.T, c *BrowserConfig) {
+				for _, want := range []string{"TZ=Europe/Paris", "LANG=C", "HOME=/tmp"} {
+					if !slices.Contains(c.Envs, want) {
+						t.Errorf("Envs = %q, want %q in it", c.Envs, want)
+					}
+				}
+				for _, key := range []string{"TZ", "HOME"} {
+					n := 0
+					for _, env := range c.Envs {
+						if k, _, _ := strings.Cut(env, "="); k == key {
+							n++
+						}
+					}
+					if n != 1 {
+						t.Errorf("Envs = %q, want a single %s entry", c.Envs, key)
+					}
+				}
+			},
+		},
+		{
+			name: "browser environment variables keep the inherited environment",
+			env:  map[string]string{"BROWSER_ENV_TZ": "UTC"},
+			check: func(t *testing.T, c *BrowserConfig) {
+				if want := "PATH=" + os.Getenv("PATH"); !slices.Contains(c.Envs, want) {
+					t.Errorf("Envs = %q, want %q inherited", c.Envs, want)
+				}
+				if !slices.Contains(c.Envs, "TZ=UTC") {
+					t.Errorf("Envs = %q, want TZ=UTC in it", c.Envs)
+				}
+			},
+		},
+		{
+			name: "lists from the environment are comma separated",
+			env:  map[string]string{"EXTENSIONS": "./a, ./b,", "PROXY_BYPASS": "localhost,*.internal"},
+			check: func(t *testing.T, c *BrowserConfig) {
+				if want := []string{"./a", "./b"}; !slices.Equal(c.Extensions, want) {
+					t.Errorf("Extensions = %q, want %q", c.Extensions, want)
+				}
+				if c.Proxy == nil || !slices.Equal(c.Proxy.Bypass, []string{"localhost", "*.internal"}) {
+					t.Errorf("Proxy = %+v, want the bypass list", c.Proxy)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(envPrefix+k, v)
+			}
+			// proxies need a server to validate
+			if tt.env["PROXY_BYPASS"] != "" && tt.file == "" {
+				t.Setenv(envPrefix+"PROXY_SERVER", "http://proxy:3128")
+			}
+
+			var path string
+			if tt.file != "" {
+				path = writeConfig(t, "browser.yaml", tt.file)
+			}
+
+			c, err := LoadBrowserConfig(path)
+			if err != nil {
+				t.Fatalf("LoadBrowserConfig() error = %v", err)
+			}
+			tt.check(t, c)
+		})
+	}
+}
+
+func TestLoadBrowserConfigFromEnvPath(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv(envPrefix+"CONFIG", writeConfig(t, "browser.yml", "max_restarts: 4\n"))
+
+	c, err := LoadBrowserConfig("")
+	if err != nil {
+		t.Fatalf("LoadBrowserConfig() error = %v", err)
+	}
+	if c.MaxRestarts != 4 {
+		t.Errorf("MaxRestarts = %d, want 4 from GOPILOT_CONFIG", c.MaxRestarts)
+	}
+}
+
+func TestLoadBrowserConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		env     map[string]string
+		want    []ConfigError // Err is not compared
+	}{
+		{
+			name:    "unknown yaml key",
+			file:    "browser.yaml",
+			content: "path: /bin/chrome\nheadles: true\n",
+			want:    []ConfigError{{Source: "browser.yaml"}},
+		},
+		{
+			name:    "unknown json key",
+			file:    "browser.json",
+			content: `{"proxy": {"server": "http://proxy:3128", "user": "x"}}`,
+			want:    []ConfigError{{Source: "browser.json"}},
+		},
+		{
+			name:    "unsupported extension",
+			file:    "browser.toml",
+			content: "path = '/bin/chrome'\n",
+			want:    []ConfigError{{Source: "browser.toml"}},
+		},
+		{
+			name:    "every invalid value is reported",
+			file:    "browser.yaml",
+			content: "launch_timeout: soon\npresets: [ci, unknown]\nenvs: [TZ]\nmax_restarts: -1\n",
+			env:     map[string]string{"LAUNCH_RETRIES": "many", "HEADLESS": "maybe", "DEBUG_PORT": "70000"},
+			want: []ConfigError{
+				{Source: "browser.yaml", Field: "presets"},
+				{Source: "browser.yaml", Field: "envs"},
+				{Source: "browser.yaml", Field: "launch_timeout"},
+				{Source: "$GOPILOT_HEADLESS"},
+				{Source: "$GOPILOT_LAUNCH_RETRIES"},
+				{Source: "validation", Field: "debug_port"},
+				{Source: "validation", Field: "max_restarts"},
+			},
+		},
+		{
+			name: "proxy without server",
+			env:  map[string]string{"PROXY_USERNAME": "user"},
+			want: []ConfigError{{Source: "validation", Field: "proxy"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(envPrefix+k, v)
+			}
+
+			var path string
+			if tt.file != "" {
+				path = writeConfig(t, tt.file, tt.content)
+			}
+
+			c, err := LoadBrowserConfig(path)
+			if err == nil {
+				t.Fatalf("LoadBrowserConfig() = %+v, want an error", c)
+			}
+
+			var errs []error
+			if joined, ok := err.(interface{ Unwrap() []error }); ok {
+				errs = joined.Unwrap()
+			} else {
+				errs = []error{err}
+			}
+
+			var got []ConfigError
+			for _, e := range errs {
+				var ce *ConfigError
+				if !errors.As(e, &ce) {
+					t.Fatalf("error %v is not a *ConfigError", e)
+				}
+				got = append(got, ConfigError{Source: filepath.Base(ce.Source), Field: ce.Field})
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("errors = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
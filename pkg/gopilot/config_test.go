@@ -0,0 +1,41 @@
+package gopilot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBrowserConfigValidateExtensions(t *testing.T) {
+	ext := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ext, "manifest.json"), []byte(`{}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		execPath string
+		args     []string
+		wantErr  bool
+	}{
+		{name: "headful", execPath: "/usr/bin/chromium"},
+		{name: "bare headless", execPath: "/usr/bin/chromium", args: []string{"--headless"}},
+		{name: "new headless", execPath: "/usr/bin/chromium", args: []string{"--headless=new"}},
+		{name: "old headless", execPath: "/usr/bin/chromium", args: []string{"--headless=old"}, wantErr: true},
+		{name: "headless shell", execPath: "/opt/chrome-headless-shell", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &BrowserConfig{Flags: NewFlags(), Args: tt.args, Extensions: []string{ext}}
+			if err := c.validateExtensions(tt.execPath); (err != nil) != tt.wantErr {
+				t.Errorf("validateExtensions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	c := &BrowserConfig{Flags: NewFlags(), Extensions: []string{t.TempDir()}}
+	if err := c.validateExtensions("/usr/bin/chromium"); err == nil {
+		t.Error("validateExtensions() error = nil for a directory without manifest.json")
+	}
+}
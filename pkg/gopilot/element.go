@@ -26,6 +26,10 @@ type Element interface {
 	// GetRect retrieves the bounding rectangle of the element.
 	// Returns a BoundingRect containing the dimensions and position of the element or an error if retrieval fails.
 	GetRect(ctx context.Context) (*BoundingRect, error)
+
+	// WaitForSelector waits for a descendant of the element matching the selector
+	// to reach a state, like Page.WaitForSelector.
+	WaitForSelector(ctx context.Context, in *ElementWaitForSelectorInput) (*ElementWaitForSelectorOutput, error)
 }
 
 // element is an implementation of the Element interface.
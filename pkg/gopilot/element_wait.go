@@ -0,0 +1,37 @@
+package gopilot
+
+import (
+	"context"
+)
+
+// ElementWaitForSelectorInput specifies the input for the WaitForSelector method.
+type ElementWaitForSelectorInput struct {
+	Selector string
+	Type     SelectorType // How the selector is matched, SelectorCSS by default.
+	State    ElementState // The state to wait for, ElementAttached by default.
+}
+
+// ElementWaitForSelectorOutput represents the output of the WaitForSelector method.
+type ElementWaitForSelectorOutput struct {
+	// Element is the matched element, nil when waiting for ElementDetached or ElementHidden.
+	Element Element
+}
+
+// WaitForSelector waits until a descendant of the element matching the
+// selector reaches the given state and returns it. Unlike the page wait,
+// it fails once the element's document is replaced.
+func (e *element) WaitForSelector(ctx context.Context, in *ElementWaitForSelectorInput) (*ElementWaitForSelectorOutput, error) {
+	if err := validateSelectorWait(in.Type, in.State); err != nil {
+		return nil, err
+	}
+
+	el, err := waitForSelector(ctx, e.client, *e.remoteObj.ObjectID, in.Selector, in.Type, in.State)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	return &ElementWaitForSelectorOutput{Element: el}, nil
+}
@@ -0,0 +1,112 @@
+package gopilot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrExecutableNotFound is returned when the browser executable cannot be resolved.
+	ErrExecutableNotFound = errors.New("browser executable not found")
+
+	// ErrLaunchTimeout is returned when the browser does not expose its DevTools endpoint in time.
+	ErrLaunchTimeout = errors.New("timeout waiting for browser devtools")
+
+	// ErrBrowserExited is returned when the browser process exits before it is ready
+	// or, once running, when it exits without Close being called.
+	ErrBrowserExited = errors.New("browser exited unexpectedly")
+
+	// ErrBrowserClosed is reported by Browser.Err after Close.
+	ErrBrowserClosed = errors.New("browser closed")
+
+	// ErrNavigationFailed is returned when the browser could not load a
+	// document, e.g. on DNS or connection failures.
+	ErrNavigationFailed = errors.New("navigation failed")
+
+	// ErrNoHistoryEntry is returned when a history navigation has no entry to go to.
+	ErrNoHistoryEntry = errors.New("no history entry")
+
+	// ErrNavigationTimeout is returned when a navigation does not reach its
+	// wait condition within the given timeout.
+	ErrNavigationTimeout = errors.New("navigation timeout")
+)
+
+// LaunchError describes a failed attempt to start the browser process.
+// It matches one of ErrExecutableNotFound, ErrLaunchTimeout or ErrBrowserExited
+// through errors.Is and keeps the last lines written by the browser.
+type LaunchError struct {
+	Err      error    // Err is the sentinel error describing the failure.
+	Cause    error    // Cause is the underlying error, e.g. the process exit status.
+	Attempts int      // Attempts is the number of launch attempts made.
+	Output   []string // Output holds the tail of the browser's stdout and stderr.
+}
+
+// Error implements the error interface.
+func (e *LaunchError) Error() string {
+	msg := e.Err.Error()
+	if e.Cause != nil {
+		msg = fmt.Sprintf("%s: %s", msg, e.Cause)
+	}
+	if e.Attempts > 1 {
+		msg = fmt.Sprintf("%s (after %d attempts)", msg, e.Attempts)
+	}
+	if len(e.Output) > 0 {
+		msg = fmt.Sprintf("%s\n%s", msg, strings.Join(e.Output, "\n"))
+	}
+	return msg
+}
+
+// Unwrap exposes both the sentinel error and its cause to errors.Is and errors.As.
+func (e *LaunchError) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Err, e.Cause}
+}
+
+// NavigationTimeoutError describes a navigation that did not reach its wait
+// condition in time. It matches ErrNavigationTimeout through errors.Is.
+type NavigationTimeoutError struct {
+	WaitUntil WaitUntil     // WaitUntil is the condition that was not met.
+	Timeout   time.Duration // Timeout is the time allowed for the navigation.
+	Reached   []string      // Reached lists the lifecycle events seen for the new document.
+}
+
+// Error implements the error interface.
+func (e *NavigationTimeoutError) Error() string {
+	return fmt.Sprintf("%s: %s exceeded waiting for %s (reached: %s)",
+		ErrNavigationTimeout, e.Timeout, e.WaitUntil, strings.Join(e.Reached, ", "))
+}
+
+// Unwrap returns ErrNavigationTimeout.
+func (e *NavigationTimeoutError) Unwrap() error {
+	return ErrNavigationTimeout
+}
+
+// NavigationError describes a navigation the browser could not complete.
+// It matches ErrNavigationFailed through errors.Is.
+type NavigationError struct {
+	URL       string // URL is the navigated URL.
+	ErrorText string // ErrorText is the browser message, e.g. "net::ERR_NAME_NOT_RESOLVED".
+	Code      string // Code is the net error code, e.g. "ERR_NAME_NOT_RESOLVED", empty for other failures.
+}
+
+func newNavigationError(url string, text string) *NavigationError {
+	code, ok := strings.CutPrefix(text, "net::")
+	if !ok {
+		code = ""
+	}
+	return &NavigationError{URL: url, ErrorText: text, Code: code}
+}
+
+// Error implements the error interface.
+func (e *NavigationError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrNavigationFailed, e.URL, e.ErrorText)
+}
+
+// Unwrap returns ErrNavigationFailed.
+func (e *NavigationError) Unwrap() error {
+	return ErrNavigationFailed
+}
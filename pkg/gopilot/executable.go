@@ -0,0 +1,166 @@
+package gopilot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// executableCheckTimeout bounds the --version run validating a candidate.
+const executableCheckTimeout = 5 * time.Second
+
+// executableWaitDelay bounds the wait for the output of a candidate once it
+// exited or timed out.
+const executableWaitDelay = time.Second
+
+// versionPattern matches the --version output of Chrome, Chromium and
+// the headless shell, e.g. "Google Chrome 126.0.6478.126".
+var versionPattern = regexp.MustCompile(`(?i)(chrome|chromium)\b.*\d+\.\d+`)
+
+// executableNames are the browser binaries looked up in PATH, in order of preference.
+var executableNames = []string{
+	"google-chrome-stable",
+	"google-chrome",
+	"chromium",
+	"chromium-browser",
+	"chrome",
+	"chrome-headless-shell",
+	"headless_shell",
+}
+
+// executablePaths are well-known Linux install locations checked after PATH.
+var executablePaths = []string{
+	"/opt/google/chrome/chrome",
+	"/opt/google/chrome-beta/chrome",
+	"/opt/google/chrome-unstable/chrome",
+	"/usr/lib/chromium/chromium",
+	"/usr/lib/chromium-browser/chromium-browser",
+	"/usr/lib64/chromium-browser/chromium-browser",
+	"/snap/bin/chromium",
+	"/headless-shell/headless-shell",
+}
+
+// playwrightPatterns match the browsers installed by Playwright, relative to its cache dir.
+var playwrightPatterns = []string{
+	"chromium-*/chrome-linux/chrome",
+	"chromium-*/chrome-linux64/chrome",
+	"chromium_headless_shell-*/chrome-linux/headless_shell",
+	"chromium_headless_shell-*/chrome-headless-shell-linux64/chrome-headless-shell",
+}
+
+// FindExecutable looks for a Chrome or Chromium executable.
+// It honours GOPILOT_CHROME_EXECUTABLE, then searches PATH, well-known Linux
+// install locations and the Playwright browser cache, returning the first
+// candidate that runs and reports a Chrome or Chromium version.
+// Returns ErrExecutableNotFound otherwise.
+func FindExecutable() (string, error) {
+	if p := os.Getenv("GOPILOT_CHROME_EXECUTABLE"); p != "" {
+		execPath, err := exec.LookPath(p)
+		if err != nil {
+			return "", err
+		}
+		if err = checkExecutable(execPath); err != nil {
+			return "", fmt.Errorf("%w: %s: %w", ErrExecutableNotFound, execPath, err)
+		}
+		return execPath, nil
+	}
+
+	var candidates []string
+	for _, name := range executableNames {
+		if p, err := exec.LookPath(name); err == nil {
+			candidates = append(candidates, p)
+		}
+	}
+	candidates = append(candidates, executablePaths...)
+	candidates = append(candidates, playwrightCandidates()...)
+
+	for _, p := range candidates {
+		if isExecutable(p) && checkExecutable(p) == nil {
+			return p, nil
+		}
+	}
+
+	return "", ErrExecutableNotFound
+}
+
+// resolveExecutable returns the browser executable to launch: path when it
+// resolves, the result of FindExecutable when it is empty or does not.
+func resolveExecutable(path string) (string, error) {
+	if path == "" {
+		return FindExecutable()
+	}
+
+	execPath, err := exec.LookPath(path)
+	if err == nil {
+		return execPath, nil
+	}
+	if found, fErr := FindExecutable(); fErr == nil {
+		return found, nil
+	}
+	return "", err
+}
+
+// playwrightCandidates lists the Playwright cached browsers, newest revision first.
+func playwrightCandidates() []string {
+	cacheDir := os.Getenv("PLAYWRIGHT_BROWSERS_PATH")
+	if cacheDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		cacheDir = filepath.Join(home, ".cache", "ms-playwright")
+	}
+
+	var candidates []string
+	for _, pattern := range playwrightPatterns {
+		matches, _ := filepath.Glob(filepath.Join(cacheDir, pattern))
+		sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+		candidates = append(candidates, matches...)
+	}
+
+	return candidates
+}
+
+// isExecutable reports whether path is a regular file with an execute bit set.
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return info.Mode().IsRegular() && info.Mode().Perm()&0o111 != 0
+}
+
+// checkExecutable runs path with --version, failing when it does not exit
+// in time or does not report a Chrome or Chromium version.
+func checkExecutable(path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), executableCheckTimeout)
+	defer cancel()
+
+	// a candidate may leave children holding stdout, e.g. a wrapper starting
+	// the browser, so the whole group is killed and the pipe is not waited on
+	cmd := exec.CommandContext(ctx, path, "--version")
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error { return killProcess(cmd) }
+	cmd.WaitDelay = executableWaitDelay
+	defer func() { _ = killProcess(cmd) }()
+
+	// ErrWaitDelay means it exited successfully with its output still held open
+	out, err := cmd.Output()
+	if err != nil && !errors.Is(err, exec.ErrWaitDelay) {
+		return fmt.Errorf("unable to run --version: %w", err)
+	}
+
+	version := string(bytes.TrimSpace(out))
+	if !versionPattern.MatchString(version) {
+		return fmt.Errorf("unexpected --version output %q", version)
+	}
+
+	return nil
+}
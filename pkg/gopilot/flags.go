@@ -0,0 +1,244 @@
+package gopilot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// FlagPreset names a predefined set of flags, see Flags.ApplyPreset.
+type FlagPreset string
+
+const (
+	// PresetCI runs headless with GPU and background throttling disabled,
+	// for stable timings on continuous integration runners.
+	PresetCI FlagPreset = "ci"
+
+	// PresetLowMemory limits renderer processes and disables memory hungry features.
+	PresetLowMemory FlagPreset = "low-memory"
+
+	// PresetDocker disables the sandbox, /dev/shm and the GPU, which are
+	// usually unavailable to an unprivileged container.
+	PresetDocker FlagPreset = "docker"
+)
+
+// presets holds the flags applied by each FlagPreset.
+var presets = map[FlagPreset]func(f *Flags){
+	PresetCI: func(f *Flags) {
+		f.SetHeadless(true).
+			SetGPU(false).
+			Set("mute-audio", "").
+			Set("hide-scrollbars", "").
+			Set("disable-background-timer-throttling", "").
+			Set("disable-backgrounding-occluded-windows", "").
+			Set("disable-renderer-backgrounding", "")
+	},
+	PresetLowMemory: func(f *Flags) {
+		f.Set("disable-dev-shm-usage", "").
+			Set("renderer-process-limit", "2").
+			Set("disable-background-networking", "").
+			Set("disable-component-update", "").
+			DisableFeatures("Translate", "BackForwardCache", "MediaRouter", "OptimizationHints")
+	},
+	PresetDocker: func(f *Flags) {
+		f.SetSandbox(false).
+			SetGPU(false).
+			Set("disable-dev-shm-usage", "")
+	},
+}
+
+// Flags is a set of browser command-line switches keyed by name, so setting
+// a switch again replaces its value instead of adding a duplicate.
+// The enabled and disabled feature lists are kept apart from the switches and
+// rendered as single --enable-features and --disable-features switches, since
+// the browser only honors the last occurrence of each.
+type Flags struct {
+	names      []string // switch names in insertion order
+	values     map[string]string
+	enabled    []string
+	disabled   []string
+	positional []string
+}
+
+// NewFlags creates an empty flag set.
+func NewFlags() *Flags {
+	return &Flags{values: map[string]string{}}
+}
+
+// ParseFlags creates a flag set from raw command-line arguments such as
+// "--window-size=1920,1080" or "--no-sandbox". Arguments that are not
+// switches are kept in order and rendered after them.
+func ParseFlags(args []string) *Flags {
+	f := NewFlags()
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			f.positional = append(f.positional, arg)
+			continue
+		}
+
+		name, value, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		switch name {
+		case "enable-features":
+			f.EnableFeatures(strings.Split(value, ",")...)
+		case "disable-features":
+			f.DisableFeatures(strings.Split(value, ",")...)
+		default:
+			f.Set(name, value)
+		}
+	}
+	return f
+}
+
+// Set sets the switch name, with or without its leading dashes, to value.
+// An empty value renders the switch without "=".
+func (f *Flags) Set(name string, value string) *Flags {
+	name = strings.TrimLeft(name, "-")
+	if f.values == nil {
+		f.values = map[string]string{}
+	}
+	if _, ok := f.values[name]; !ok {
+		f.names = append(f.names, name)
+	}
+	f.values[name] = value
+	return f
+}
+
+// Unset removes the switch name.
+func (f *Flags) Unset(name string) *Flags {
+	name = strings.TrimLeft(name, "-")
+	if _, ok := f.values[name]; !ok {
+		return f
+	}
+	delete(f.values, name)
+	f.names = slices.DeleteFunc(f.names, func(n string) bool { return n == name })
+	return f
+}
+
+// Get returns the value of the switch name and whether it is set.
+func (f *Flags) Get(name string) (string, bool) {
+	v, ok := f.values[strings.TrimLeft(name, "-")]
+	return v, ok
+}
+
+// Has reports whether the switch name is set.
+func (f *Flags) Has(name string) bool {
+	_, ok := f.Get(name)
+	return ok
+}
+
+// Merge applies the switches, features and positional arguments of other on
+// top of f, values of other win on conflicts.
+func (f *Flags) Merge(other *Flags) *Flags {
+	if other == nil {
+		return f
+	}
+	for _, name := range other.names {
+		f.Set(name, other.values[name])
+	}
+	f.EnableFeatures(other.enabled...)
+	f.DisableFeatures(other.disabled...)
+	f.positional = append(f.positional, other.positional...)
+	return f
+}
+
+// Clone returns a copy of f.
+func (f *Flags) Clone() *Flags {
+	return NewFlags().Merge(f)
+}
+
+// ApplyPreset merges the flags of a named preset into f.
+func (f *Flags) ApplyPreset(preset FlagPreset) error {
+	apply, ok := presets[preset]
+	if !ok {
+		return fmt.Errorf("unknown flag preset %q", preset)
+	}
+	apply(f)
+	return nil
+}
+
+// SetWindowSize sets the initial window size.
+func (f *Flags) SetWindowSize(width int, height int) *Flags {
+	return f.Set("window-size", fmt.Sprintf("%d,%d", width, height))
+}
+
+// SetLang sets the browser UI and Accept-Language locale, e.g. "en-US".
+func (f *Flags) SetLang(lang string) *Flags {
+	return f.Set("lang", lang)
+}
+
+// SetHeadless enables the new headless mode or, when false, removes it.
+func (f *Flags) SetHeadless(headless bool) *Flags {
+	if !headless {
+		return f.Unset("headless")
+	}
+	return f.Set("headless", "new")
+}
+
+// SetGPU enables or disables GPU hardware acceleration.
+func (f *Flags) SetGPU(enabled bool) *Flags {
+	if enabled {
+		return f.Unset("disable-gpu")
+	}
+	return f.Set("disable-gpu", "")
+}
+
+// SetSandbox enables or disables the renderer sandbox.
+// Disabling it is often required when running as root in a container.
+func (f *Flags) SetSandbox(enabled bool) *Flags {
+	if enabled {
+		return f.Unset("no-sandbox")
+	}
+	return f.Set("no-sandbox", "")
+}
+
+// SetUserAgent overrides the default User-Agent.
+func (f *Flags) SetUserAgent(ua string) *Flags {
+	return f.Set("user-agent", ua)
+}
+
+// EnableFeatures adds features to --enable-features, removing them from the disabled list.
+func (f *Flags) EnableFeatures(features ...string) *Flags {
+	for _, feature := range features {
+		if feature == "" {
+			continue
+		}
+		f.disabled = slices.DeleteFunc(f.disabled, func(d string) bool { return d == feature })
+		if !slices.Contains(f.enabled, feature) {
+			f.enabled = append(f.enabled, feature)
+		}
+	}
+	return f
+}
+
+// DisableFeatures adds features to --disable-features, removing them from the enabled list.
+func (f *Flags) DisableFeatures(features ...string) *Flags {
+	for _, feature := range features {
+		if feature == "" {
+			continue
+		}
+		f.enabled = slices.DeleteFunc(f.enabled, func(e string) bool { return e == feature })
+		if !slices.Contains(f.disabled, feature) {
+			f.disabled = append(f.disabled, feature)
+		}
+	}
+	return f
+}
+
+// Args renders the flag set as command-line arguments.
+func (f *Flags) Args() []string {
+	args := make([]string, 0, len(f.names)+2+len(f.positional))
+	for _, name := range f.names {
+		if v := f.values[name]; v != "" {
+			args = append(args, fmt.Sprintf("--%s=%s", name, v))
+		} else {
+			args = append(args, "--"+name)
+		}
+	}
+	if len(f.enabled) > 0 {
+		args = append(args, "--enable-features="+strings.Join(f.enabled, ","))
+	}
+	if len(f.disabled) > 0 {
+		args = append(args, "--disable-features="+strings.Join(f.disabled, ","))
+	}
+	return append(args, f.positional...)
+}
@@ -0,0 +1,236 @@
+package gopilot
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "switches keep their order and values",
+			args: []string{"--no-sandbox", "--window-size=1920,1080", "-lang=en-US"},
+			want: []string{"--no-sandbox", "--window-size=1920,1080", "--lang=en-US"},
+		},
+		{
+			name: "a repeated switch keeps its first position and last value",
+			args: []string{"--lang=en-US", "--no-sandbox", "--lang=fr-FR"},
+			want: []string{"--lang=fr-FR", "--no-sandbox"},
+		},
+		{
+			name: "feature lists are merged into a single switch",
+			args: []string{"--enable-features=A,B", "--disable-features=C", "--enable-features=B,D"},
+			want: []string{"--enable-features=A,B,D", "--disable-features=C"},
+		},
+		{
+			name: "a feature moves to the last list it is set in",
+			args: []string{"--enable-features=A,B", "--disable-features=A"},
+			want: []string{"--enable-features=B", "--disable-features=A"},
+		},
+		{
+			name: "positional arguments are rendered last",
+			args: []string{"about:blank", "--no-sandbox"},
+			want: []string{"--no-sandbox", "about:blank"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseFlags(tt.args).Args(); !slices.Equal(got, tt.want) {
+				t.Errorf("Args() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlagsSetters(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags func() *Flags
+		want  []string
+	}{
+		{
+			name:  "unset removes the switch with or without dashes",
+			flags: func() *Flags { return ParseFlags([]string{"--a", "--b=1", "--c"}).Unset("--b").Unset("c") },
+			want:  []string{"--a"},
+		},
+		{
+			name:  "unset of a missing switch is a no-op",
+			flags: func() *Flags { return ParseFlags([]string{"--a"}).Unset("b") },
+			want:  []string{"--a"},
+		},
+		{
+			name:  "set after unset appends the switch again",
+			flags: func() *Flags { return ParseFlags([]string{"--a", "--b"}).Unset("a").Set("a", "2") },
+			want:  []string{"--b", "--a=2"},
+		},
+		{
+			name:  "headless off removes the switch",
+			flags: func() *Flags { return NewFlags().SetHeadless(true).SetHeadless(false) },
+			want:  []string{},
+		},
+		{
+			name:  "gpu and sandbox toggles",
+			flags: func() *Flags { return NewFlags().SetGPU(false).SetSandbox(false).SetGPU(true) },
+			want:  []string{"--no-sandbox"},
+		},
+		{
+			name:  "window size, lang and user agent",
+			flags: func() *Flags { return NewFlags().SetWindowSize(800, 600).SetLang("en-US").SetUserAgent("bot") },
+			want:  []string{"--window-size=800,600", "--lang=en-US", "--user-agent=bot"},
+		},
+		{
+			name:  "features are deduplicated and empty names ignored",
+			flags: func() *Flags { return NewFlags().EnableFeatures("A", "", "A").DisableFeatures("B", "B") },
+			want:  []string{"--enable-features=A", "--disable-features=B"},
+		},
+		{
+			name:  "enabling a disabled feature removes it from the disabled list",
+			flags: func() *Flags { return NewFlags().DisableFeatures("A", "B").EnableFeatures("A") },
+			want:  []string{"--enable-features=A", "--disable-features=B"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.flags().Args(); !slices.Equal(got, tt.want) {
+				t.Errorf("Args() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlagsMerge(t *testing.T) {
+	base := ParseFlags([]string{"--lang=en-US", "--no-sandbox", "--enable-features=A", "--disable-features=B", "page.html"})
+	other := ParseFlags([]string{"--lang=fr-FR", "--mute-audio", "--enable-features=B", "other.html"})
+
+	got := base.Clone().Merge(other).Args()
+	want := []string{
+		"--lang=fr-FR",
+		"--no-sandbox",
+		"--mute-audio",
+		"--enable-features=A,B",
+		"page.html",
+		"other.html",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Merge() = %q, want %q", got, want)
+	}
+
+	// the clone is independent of its source
+	if v, _ := base.Get("lang"); v != "en-US" {
+		t.Errorf("base lang = %q after merging into its clone, want en-US", v)
+	}
+	if base.Has("mute-audio") {
+		t.Error("base has mute-audio after merging into its clone")
+	}
+
+	if got := base.Clone().Merge(nil).Args(); !slices.Equal(got, base.Args()) {
+		t.Errorf("Merge(nil) = %q, want %q", got, base.Args())
+	}
+}
+
+func TestFlagsApplyPreset(t *testing.T) {
+	tests := []struct {
+		preset  FlagPreset
+		has     []string
+		missing []string
+	}{
+		{
+			preset:  PresetCI,
+			has:     []string{"headless", "disable-gpu", "mute-audio", "disable-renderer-backgrounding"},
+			missing: []string{"no-sandbox"},
+		},
+		{
+			preset:  PresetLowMemory,
+			has:     []string{"disable-dev-shm-usage", "renderer-process-limit", "disable-features"},
+			missing: []string{"headless"},
+		},
+		{
+			preset:  PresetDocker,
+			has:     []string{"no-sandbox", "disable-gpu", "disable-dev-shm-usage"},
+			missing: []string{"headless"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.preset), func(t *testing.T) {
+			f := NewFlags()
+			if err := f.ApplyPreset(tt.preset); err != nil {
+				t.Fatalf("ApplyPreset() error = %v", err)
+			}
+
+			var names []string
+			for _, arg := range f.Args() {
+				name, _, _ := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+				names = append(names, name)
+			}
+			for _, name := range tt.has {
+				if !slices.Contains(names, name) {
+					t.Errorf("missing switch %q in %q", name, f.Args())
+				}
+			}
+			for _, name := range tt.missing {
+				if slices.Contains(names, name) {
+					t.Errorf("unexpected switch %q in %q", name, f.Args())
+				}
+			}
+		})
+	}
+
+	if err := NewFlags().ApplyPreset("unknown"); err == nil {
+		t.Error("ApplyPreset(unknown) error = nil, want an error")
+	}
+}
+
+func TestBrowserConfigLaunchFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+		args  []string
+		want  []string
+	}{
+		{
+			name:  "args override flags",
+			flags: []string{"--lang=en-US", "--headless=new"},
+			args:  []string{"--lang=fr-FR"},
+			want:  []string{"--lang=fr-FR", "--headless=new"},
+		},
+		{
+			name:  "args features are merged into the flags features",
+			flags: []string{"--disable-features=A,B"},
+			args:  []string{"--enable-features=B,C"},
+			want:  []string{"--enable-features=B,C", "--disable-features=A"},
+		},
+		{
+			name: "no flags",
+			args: []string{"--no-sandbox"},
+			want: []string{"--no-sandbox"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &BrowserConfig{Args: tt.args}
+			if tt.flags != nil {
+				c.Flags = ParseFlags(tt.flags)
+			}
+
+			if got := c.launchFlags().Args(); !slices.Equal(got, tt.want) {
+				t.Errorf("launchFlags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// rendering does not alter the configured flags
+	c := &BrowserConfig{Flags: ParseFlags([]string{"--lang=en-US"}), Args: []string{"--lang=fr-FR"}}
+	_ = c.launchFlags()
+	if v, _ := c.Flags.Get("lang"); v != "en-US" {
+		t.Errorf("Flags lang = %q after launchFlags, want en-US", v)
+	}
+}
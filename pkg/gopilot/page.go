@@ -24,6 +24,20 @@ type Page interface {
 	// It can take a PageReloadInput and returns a PageReloadOutput or an error.
 	Reload(ctx context.Context, in *PageReloadInput) (*PageReloadOutput, error)
 
+	// GoBack navigates to the previous history entry, waiting like Navigate.
+	// It returns ErrNoHistoryEntry at the first entry.
+	GoBack(ctx context.Context, in *PageHistoryInput) (*PageHistoryOutput, error)
+
+	// GoForward navigates to the next history entry, waiting like Navigate.
+	// It returns ErrNoHistoryEntry at the last entry.
+	GoForward(ctx context.Context, in *PageHistoryInput) (*PageHistoryOutput, error)
+
+	// GetHistory returns the navigation history entries and the current index.
+	GetHistory(ctx context.Context) (*PageGetHistoryOutput, error)
+
+	// NavigateToHistoryEntry navigates to a history entry by ID, waiting like Navigate.
+	NavigateToHistoryEntry(ctx context.Context, in *PageNavigateToHistoryEntryInput) (*PageHistoryOutput, error)
+
 	// GetContent retrieves the HTML content of the page as a string.
 	// Returns the content or an error if retrieving fails.
 	GetContent(ctx context.Context) (string, error)
@@ -48,6 +62,26 @@ type Page interface {
 	// It takes a handle to the callback to be removed.
 	RemoveInterceptRequest(ctx context.Context, handle *InterceptRequestHandle)
 
+	// SetHTTPCredentials registers credentials answering HTTP authentication
+	// challenges (Basic/Digest) of an origin, or of every origin when it is empty.
+	// Fetch interception is enabled as needed.
+	SetHTTPCredentials(ctx context.Context, in *PageSetHTTPCredentialsInput) (*PageSetHTTPCredentialsOutput, error)
+
+	// SetAuthHandler sets a callback deciding how to answer authentication challenges,
+	// it takes precedence over the credentials set with SetHTTPCredentials.
+	// A nil callback removes the current one.
+	SetAuthHandler(ctx context.Context, cb AuthRequiredCallback) error
+
+	// GetWindowBounds returns the id, position, size and state of the window holding the page.
+	GetWindowBounds(ctx context.Context) (*WindowBounds, error)
+
+	// SetWindowBounds moves and resizes the window holding the page, restoring
+	// it to the normal state first when needed.
+	SetWindowBounds(ctx context.Context, in *WindowBounds) error
+
+	// SetWindowState maximizes, minimizes, restores or makes fullscreen the window holding the page.
+	SetWindowState(ctx context.Context, state WindowState) error
+
 	// Evaluate runs JavaScript on the page.
 	// Takes a PageEvaluateInput and returns a PageEvaluateOutput or an error.
 	Evaluate(ctx context.Context, in *PageEvaluateInput) (*PageEvaluateOutput, error)
@@ -60,6 +94,16 @@ type Page interface {
 	// Takes a PageSearchInput and returns a PageSearchOutput or an error.
 	Search(ctx context.Context, in *PageSearchInput) (*PageSearchOutput, error)
 
+	// WaitForFunction waits for a JavaScript predicate to return a truthy value,
+	// checking it on each animation frame, on an interval or on DOM mutations.
+	// Returns the value, the wait lasts until ctx is done.
+	WaitForFunction(ctx context.Context, in *PageWaitForFunctionInput) (*PageWaitForFunctionOutput, error)
+
+	// WaitForSelector waits for an element matching a CSS, XPath or text selector
+	// to be attached, detached, visible or hidden, until ctx is done.
+	// Returns the element, nil when waiting for it to go away.
+	WaitForSelector(ctx context.Context, in *PageWaitForSelectorInput) (*PageWaitForSelectorOutput, error)
+
 	// GetCookies retrieves cookies for the current page.
 	// Takes a GetCookiesInput and returns GetCookiesOutput or an error.
 	GetCookies(ctx context.Context, in *GetCookiesInput) (*GetCookiesOutput, error)
@@ -91,8 +135,14 @@ type page struct {
 	mux    sync.RWMutex
 	closed bool
 
-	fetchEnabled      bool
+	fetchMux          sync.Mutex // serializes fetch mode changes
+	fetchEnabled      bool       // every request and response is intercepted
+	authFetchEnabled  bool       // only authentication challenges are intercepted
 	interceptClient   fetch.RequestPausedClient
+	authClient        fetch.AuthRequiredClient
+	proxy             *ProxyConfig
+	credentials       map[string]AuthResponse
+	authHandler       AuthRequiredCallback
 	interceptRequests map[*InterceptRequestHandle]InterceptRequestCallback
 }
 
@@ -109,6 +159,16 @@ func newPage(
 		return nil, err
 	}
 
+	return newPageWithConn(ctx, t, conn, logger)
+}
+
+// newPageWithConn creates a new Page instance over an established target connection.
+func newPageWithConn(
+	ctx context.Context,
+	t *devtool.Target,
+	conn *rpcc.Conn,
+	logger *slog.Logger,
+) (Page, error) {
 	logger.Debug("creating protocol client")
 	client := cdp.NewClient(conn)
 	p := &page{
@@ -119,11 +179,13 @@ func newPage(
 		logger:            logger,
 		mux:               sync.RWMutex{},
 		interceptRequests: map[*InterceptRequestHandle]InterceptRequestCallback{},
+		credentials:       map[string]AuthResponse{},
 	}
 
 	// Enable events on the Page domain, it's often preferable to create
 	// event clients before enabling events so that we don't miss any.
-	if err = p.client.Page.Enable(ctx); err != nil {
+	if err := p.client.Page.Enable(ctx); err != nil {
+		_ = conn.Close()
 		return nil, err
 	}
 
@@ -146,6 +208,22 @@ func (p *page) Close(ctx context.Context) error {
 	return nil
 }
 
+// isClosed reports whether the page was closed or its target destroyed.
+func (p *page) isClosed() bool {
+	p.mux.RLock()
+	defer p.mux.RUnlock()
+	return p.closed
+}
+
+// markClosed flags the page as closed and releases its connection,
+// the target itself is left alone.
+func (p *page) markClosed() {
+	p.mux.Lock()
+	p.closed = true
+	p.mux.Unlock()
+	_ = p.conn.Close()
+}
+
 // PageEvaluateInput specifies input for the Evaluate method.
 type PageEvaluateInput struct {
 	AwaitPromise bool
@@ -160,16 +238,12 @@ type PageEvaluateOutput struct {
 
 // Evaluate executes the given JavaScript expression on the page.
 func (p *page) Evaluate(ctx context.Context, in *PageEvaluateInput) (*PageEvaluateOutput, error) {
-	userGesture := true
-	allowUnsafe := true
+	return evaluate(ctx, p.client, in)
+}
 
-	res, err := p.client.Runtime.Evaluate(ctx, &runtime.EvaluateArgs{
-		Expression:                  in.Expression,
-		UserGesture:                 &userGesture,
-		ReturnByValue:               &in.ReturnValue,
-		AwaitPromise:                &in.AwaitPromise,
-		AllowUnsafeEvalBlockedByCSP: &allowUnsafe,
-	})
+// evaluate executes the JavaScript expression through the Runtime domain of client.
+func evaluate(ctx context.Context, client *cdp.Client, in *PageEvaluateInput) (*PageEvaluateOutput, error) {
+	res, err := evaluateReply(ctx, client, in)
 	if err != nil {
 		return nil, err
 	}
@@ -182,6 +256,21 @@ func (p *page) Evaluate(ctx context.Context, in *PageEvaluateInput) (*PageEvalua
 	return out, nil
 }
 
+// evaluateReply calls Runtime.evaluate for evaluate, returning the reply with
+// the exception details, if any, to callers that need them.
+func evaluateReply(ctx context.Context, client *cdp.Client, in *PageEvaluateInput) (*runtime.EvaluateReply, error) {
+	userGesture := true
+	allowUnsafe := true
+
+	return client.Runtime.Evaluate(ctx, &runtime.EvaluateArgs{
+		Expression:                  in.Expression,
+		UserGesture:                 &userGesture,
+		ReturnByValue:               &in.ReturnValue,
+		AwaitPromise:                &in.AwaitPromise,
+		AllowUnsafeEvalBlockedByCSP: &allowUnsafe,
+	})
+}
+
 // GetTargetID returns the unique identifier for the page's target.
 // This ID can be used to distinguish different pages or targets in the browser.
 func (p *page) GetTargetID() string {
@@ -0,0 +1,292 @@
+package gopilot
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/mafredri/cdp/protocol/fetch"
+)
+
+const authChallengeSourceProxy = "Proxy"
+
+// AuthAction is the decision taken on an authentication challenge.
+type AuthAction string
+
+const (
+	AuthDefault            AuthAction = "Default"            // Defer to the browser, which usually cancels the challenge.
+	AuthCancel             AuthAction = "CancelAuth"         // Cancel the challenge, the request gets the 401/407 response.
+	AuthProvideCredentials AuthAction = "ProvideCredentials" // Answer the challenge with Username and Password.
+)
+
+// AuthChallenge describes an authentication challenge received by the page.
+type AuthChallenge struct {
+	URL    string // URL of the request that was challenged.
+	Origin string // Origin of the challenger.
+	Scheme string // Scheme is the authentication scheme, such as basic or digest.
+	Realm  string // Realm of the challenge, may be empty.
+	Proxy  bool   // Proxy reports whether the challenge comes from a proxy.
+}
+
+// AuthResponse is the answer to an authentication challenge.
+type AuthResponse struct {
+	Action   AuthAction
+	Username string // Username is only used with AuthProvideCredentials.
+	Password string // Password is only used with AuthProvideCredentials.
+}
+
+// AuthRequiredCallback decides how to answer an authentication challenge.
+// Returning an error cancels the challenge.
+type AuthRequiredCallback func(ctx context.Context, ch *AuthChallenge) (*AuthResponse, error)
+
+// PageSetHTTPCredentialsInput contains the credentials for an origin.
+type PageSetHTTPCredentialsInput struct {
+	// Origin the credentials apply to, e.g. "https://intranet.example.com".
+	// An empty Origin applies to every origin without specific credentials.
+	Origin   string
+	Username string
+	Password string
+
+	// Remove deletes the credentials of Origin instead of setting them.
+	Remove bool
+}
+
+// PageSetHTTPCredentialsOutput is returned after setting credentials successfully.
+type PageSetHTTPCredentialsOutput struct{}
+
+// SetHTTPCredentials registers the credentials answering the HTTP
+// authentication challenges of an origin and intercepts those challenges.
+func (p *page) SetHTTPCredentials(ctx context.Context, in *PageSetHTTPCredentialsInput) (*PageSetHTTPCredentialsOutput, error) {
+	origin, err := normalizeOrigin(in.Origin)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mux.Lock()
+	if in.Remove {
+		delete(p.credentials, origin)
+	} else {
+		p.credentials[origin] = AuthResponse{
+			Action:   AuthProvideCredentials,
+			Username: in.Username,
+			Password: in.Password,
+		}
+	}
+	p.mux.Unlock()
+
+	if in.Remove {
+		return &PageSetHTTPCredentialsOutput{}, nil
+	}
+
+	// interception must outlive the context used to set the credentials
+	if err = p.enableAuthFetch(context.WithoutCancel(ctx)); err != nil {
+		return nil, err
+	}
+
+	return &PageSetHTTPCredentialsOutput{}, nil
+}
+
+// SetAuthHandler sets the callback answering authentication challenges.
+func (p *page) SetAuthHandler(ctx context.Context, cb AuthRequiredCallback) error {
+	p.mux.Lock()
+	p.authHandler = cb
+	p.mux.Unlock()
+
+	if cb == nil {
+		return nil
+	}
+
+	return p.enableAuthFetch(context.WithoutCancel(ctx))
+}
+
+// setProxyAuth stores the proxy credentials of the page and enables fetch
+// interception so proxy authentication challenges are answered with them.
+func (p *page) setProxyAuth(ctx context.Context, proxy *ProxyConfig) error {
+	if !proxy.hasCredentials() {
+		return nil
+	}
+
+	p.mux.Lock()
+	p.proxy = proxy
+	p.mux.Unlock()
+
+	// interception must outlive the context used to create the page
+	return p.enableAuthFetch(context.WithoutCancel(ctx))
+}
+
+// needsAuth reports whether authentication challenges must be answered.
+func (p *page) needsAuth() bool {
+	p.mux.RLock()
+	defer p.mux.RUnlock()
+
+	return p.proxy.hasCredentials() || len(p.credentials) > 0 || p.authHandler != nil
+}
+
+// enableAuthFetch makes sure authentication challenges are answered, the
+// full interception of EnableFetch already does.
+func (p *page) enableAuthFetch(ctx context.Context) error {
+	p.fetchMux.Lock()
+	defer p.fetchMux.Unlock()
+
+	if p.fetchEnabled {
+		return nil
+	}
+	return p.startAuthFetch(ctx)
+}
+
+// startAuthFetch intercepts requests only to answer their authentication
+// challenges: every request is paused at the request stage and continued
+// right away, one round trip each, as Fetch reports challenges only for
+// intercepted requests. fetchMux must be held.
+func (p *page) startAuthFetch(ctx context.Context) error {
+	if p.authFetchEnabled {
+		return nil
+	}
+
+	auth := true
+	pattern := "*"
+	err := p.client.Fetch.Enable(ctx, &fetch.EnableArgs{
+		HandleAuthRequests: &auth,
+		Patterns: []fetch.RequestPattern{
+			{RequestStage: fetch.RequestStageRequest, URLPattern: &pattern},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	pc, err := p.client.Fetch.RequestPaused(ctx)
+	if err != nil {
+		return err
+	}
+	ac, err := p.client.Fetch.AuthRequired(ctx)
+	if err != nil {
+		_ = pc.Close()
+		return err
+	}
+
+	p.interceptClient = pc
+	p.authClient = ac
+	p.authFetchEnabled = true
+
+	go p.handleAuthRequired(ctx, ac)
+	go p.continuePaused(ctx, pc)
+
+	return nil
+}
+
+// continuePaused lets the requests paused by the auth interception through.
+func (p *page) continuePaused(ctx context.Context, pc fetch.RequestPausedClient) {
+	defer pc.Close()
+	for {
+		rp, err := pc.Recv()
+		if err != nil {
+			return
+		}
+
+		err = p.client.Fetch.ContinueRequest(ctx, &fetch.ContinueRequestArgs{RequestID: rp.RequestID})
+		if err != nil {
+			p.logger.Debug("unable to continue request", "error", err, "url", rp.Request.URL)
+		}
+	}
+}
+
+// handleAuthRequired answers the authentication challenges of intercepted requests.
+func (p *page) handleAuthRequired(ctx context.Context, ac fetch.AuthRequiredClient) {
+	defer ac.Close()
+	for {
+		rp, err := ac.Recv()
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				p.logger.Debug("auth required client closed", "error", err)
+			}
+			return
+		}
+
+		ch := &AuthChallenge{
+			URL:    rp.Request.URL,
+			Origin: rp.AuthChallenge.Origin,
+			Scheme: rp.AuthChallenge.Scheme,
+			Realm:  rp.AuthChallenge.Realm,
+			Proxy:  rp.AuthChallenge.Source != nil && *rp.AuthChallenge.Source == authChallengeSourceProxy,
+		}
+		p.logger.Debug("received auth challenge", "request_id", rp.RequestID, "origin", ch.Origin, "proxy", ch.Proxy)
+
+		res := p.resolveAuth(ctx, ch)
+
+		args := &fetch.ContinueWithAuthArgs{
+			RequestID:             rp.RequestID,
+			AuthChallengeResponse: fetch.AuthChallengeResponse{Response: string(res.Action)},
+		}
+		if res.Action == AuthProvideCredentials {
+			args.AuthChallengeResponse.Username = &res.Username
+			args.AuthChallengeResponse.Password = &res.Password
+		}
+
+		if err = p.client.Fetch.ContinueWithAuth(ctx, args); err != nil {
+			p.logger.Warn("unable to continue with auth", "error", err, "url", rp.Request.URL)
+		}
+	}
+}
+
+// resolveAuth picks the answer to a challenge: proxy credentials first,
+// then the auth handler, then the origin credentials, falling back to AuthDefault.
+func (p *page) resolveAuth(ctx context.Context, ch *AuthChallenge) *AuthResponse {
+	p.mux.RLock()
+	proxy := p.proxy
+	handler := p.authHandler
+	p.mux.RUnlock()
+
+	if ch.Proxy && proxy.hasCredentials() {
+		return &AuthResponse{
+			Action:   AuthProvideCredentials,
+			Username: proxy.Username,
+			Password: proxy.Password,
+		}
+	}
+
+	if handler != nil {
+		res, err := handler(ctx, ch)
+		if err != nil {
+			p.logger.Debug("auth handler error, cancelling challenge", "error", err, "origin", ch.Origin)
+			return &AuthResponse{Action: AuthCancel}
+		}
+		if res != nil {
+			return res
+		}
+	}
+
+	origin, err := normalizeOrigin(ch.Origin)
+	if err != nil {
+		origin = ch.Origin
+	}
+
+	p.mux.RLock()
+	defer p.mux.RUnlock()
+
+	if res, ok := p.credentials[origin]; ok {
+		return &res
+	}
+	if res, ok := p.credentials[""]; ok && !ch.Proxy {
+		return &res
+	}
+
+	return &AuthResponse{Action: AuthDefault}
+}
+
+// normalizeOrigin reduces a URL or origin to its "scheme://host[:port]" form.
+func normalizeOrigin(origin string) (string, error) {
+	if origin == "" {
+		return "", nil
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", errors.New("origin must include scheme and host: " + origin)
+	}
+
+	return u.Scheme + "://" + u.Host, nil
+}
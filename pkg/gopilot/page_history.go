@@ -0,0 +1,148 @@
+package gopilot
+
+import (
+	"context"
+	"time"
+
+	cdppage "github.com/mafredri/cdp/protocol/page"
+)
+
+// PageHistoryEntry is an entry of the page navigation history.
+type PageHistoryEntry struct {
+	ID             int    // ID identifies the entry for NavigateToHistoryEntry.
+	URL            string // URL of the entry.
+	UserTypedURL   string // UserTypedURL is the URL typed in the address bar, if any.
+	Title          string // Title of the entry document.
+	TransitionType string // TransitionType is how the entry was reached, e.g. "link" or "typed".
+}
+
+// PageGetHistoryOutput represents the output of the GetHistory method.
+type PageGetHistoryOutput struct {
+	CurrentIndex int                // CurrentIndex is the index of the current entry in Entries.
+	Entries      []PageHistoryEntry // Entries are ordered from the oldest to the newest.
+}
+
+// PageHistoryInput specifies the input for the GoBack and GoForward methods.
+type PageHistoryInput struct {
+	WaitUntil   WaitUntil     // The load condition to wait for, WaitNone returns right away.
+	QuietWindow time.Duration // How long the network must stay idle for WaitNetworkIdle0/2, 500ms by default.
+	Timeout     time.Duration // If set, a *NavigationTimeoutError is returned once exceeded.
+}
+
+// PageNavigateToHistoryEntryInput specifies the input for the NavigateToHistoryEntry method.
+type PageNavigateToHistoryEntryInput struct {
+	EntryID     int           // The ID of the entry, as reported by GetHistory.
+	WaitUntil   WaitUntil     // The load condition to wait for, WaitNone returns right away.
+	QuietWindow time.Duration // How long the network must stay idle for WaitNetworkIdle0/2, 500ms by default.
+	Timeout     time.Duration // If set, a *NavigationTimeoutError is returned once exceeded.
+}
+
+// PageHistoryOutput represents the output of the history navigation methods.
+type PageHistoryOutput struct {
+	Entry PageHistoryEntry // Entry is the history entry navigated to.
+}
+
+// GetHistory returns the navigation history of the page.
+func (p *page) GetHistory(ctx context.Context) (*PageGetHistoryOutput, error) {
+	rp, err := p.client.Page.GetNavigationHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &PageGetHistoryOutput{CurrentIndex: rp.CurrentIndex}
+	for _, e := range rp.Entries {
+		out.Entries = append(out.Entries, PageHistoryEntry{
+			ID:             e.ID,
+			URL:            e.URL,
+			UserTypedURL:   e.UserTypedURL,
+			Title:          e.Title,
+			TransitionType: string(e.TransitionType),
+		})
+	}
+
+	return out, nil
+}
+
+// GoBack navigates to the previous history entry.
+// Returns ErrNoHistoryEntry when the page is at the first entry.
+func (p *page) GoBack(ctx context.Context, in *PageHistoryInput) (*PageHistoryOutput, error) {
+	return p.goHistory(ctx, -1, in)
+}
+
+// GoForward navigates to the next history entry.
+// Returns ErrNoHistoryEntry when the page is at the last entry.
+func (p *page) GoForward(ctx context.Context, in *PageHistoryInput) (*PageHistoryOutput, error) {
+	return p.goHistory(ctx, 1, in)
+}
+
+// goHistory navigates delta entries away from the current one.
+func (p *page) goHistory(ctx context.Context, delta int, in *PageHistoryInput) (*PageHistoryOutput, error) {
+	history, err := p.GetHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	i := history.CurrentIndex + delta
+	if i < 0 || i >= len(history.Entries) {
+		return nil, ErrNoHistoryEntry
+	}
+
+	return p.NavigateToHistoryEntry(ctx, &PageNavigateToHistoryEntryInput{
+		EntryID:     history.Entries[i].ID,
+		WaitUntil:   in.WaitUntil,
+		QuietWindow: in.QuietWindow,
+		Timeout:     in.Timeout,
+	})
+}
+
+// NavigateToHistoryEntry navigates to the history entry with the given ID.
+// The entry is restored from history, so forms are not submitted again.
+// Returns ErrNoHistoryEntry when the page has no such entry.
+func (p *page) NavigateToHistoryEntry(ctx context.Context, in *PageNavigateToHistoryEntryInput) (*PageHistoryOutput, error) {
+	history, err := p.GetHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry *PageHistoryEntry
+	for i := range history.Entries {
+		if history.Entries[i].ID == in.EntryID {
+			entry = &history.Entries[i]
+			break
+		}
+	}
+	if entry == nil {
+		return nil, ErrNoHistoryEntry
+	}
+
+	w, err := p.newNavigationWaiter(ctx, in.WaitUntil, in.QuietWindow, in.Timeout, true)
+	if err != nil {
+		return nil, err
+	}
+	defer w.close()
+
+	navCtx, cancel := w.withDeadline(ctx)
+	defer cancel()
+
+	// the new document is told apart from the current one of the main frame
+	tree, err := p.client.Page.GetFrameTree(navCtx)
+	if err != nil {
+		return nil, w.timeoutError(ctx, navCtx, err)
+	}
+	frame := tree.FrameTree.Frame
+
+	p.logger.Debug("page history navigation started", "entry_id", entry.ID, "url", entry.URL)
+
+	err = p.client.Page.NavigateToHistoryEntry(navCtx, cdppage.NewNavigateToHistoryEntryArgs(entry.ID))
+	if err != nil {
+		return nil, w.timeoutError(ctx, navCtx, err)
+	}
+
+	if err = w.wait(navCtx, frame.ID, "", frame.LoaderID); err != nil {
+		return nil, w.timeoutError(ctx, navCtx, err)
+	}
+
+	p.logger.Debug("page history navigation finished", "entry_id", entry.ID)
+
+	return &PageHistoryOutput{Entry: *entry}, nil
+}
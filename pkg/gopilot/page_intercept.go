@@ -13,10 +13,17 @@ import (
 // It sets up the fetching mechanism and allows handling of authentication requests.
 // Returns an error if enabling fails.
 func (p *page) EnableFetch(ctx context.Context) error {
+	p.fetchMux.Lock()
+	defer p.fetchMux.Unlock()
+
 	if p.fetchEnabled {
 		return nil
 	}
 
+	// the full interception answers authentication challenges as well
+	p.closeFetchClients()
+	p.authFetchEnabled = false
+
 	auth := true
 	pattern := "*"
 	enableArg := &fetch.EnableArgs{
@@ -37,21 +44,48 @@ func (p *page) EnableFetch(ctx context.Context) error {
 }
 
 // DisableFetch disables network request interception.
+// Authentication challenges keep being answered while proxy or HTTP
+// credentials, or an auth handler, are set.
 // Returns an error if disabling fails.
 func (p *page) DisableFetch(ctx context.Context) error {
+	p.fetchMux.Lock()
+	defer p.fetchMux.Unlock()
+
 	if p.fetchEnabled {
-		if err := p.interceptClient.Close(); err != nil {
-			p.logger.Debug("unable to close paused request handler", "error", err)
-		}
+		p.closeFetchClients()
+		p.fetchEnabled = false
 	}
 
+	if p.needsAuth() {
+		// interception must outlive the context used to disable fetch
+		return p.startAuthFetch(context.WithoutCancel(ctx))
+	}
+
+	p.closeFetchClients()
+	p.authFetchEnabled = false
+
 	if err := p.client.Fetch.Disable(ctx); err != nil {
 		return fmt.Errorf("unable to disable fetch: %w", err)
 	}
-	p.fetchEnabled = false
 	return nil
 }
 
+// closeFetchClients stops the handlers of the current fetch mode.
+func (p *page) closeFetchClients() {
+	if p.interceptClient != nil {
+		if err := p.interceptClient.Close(); err != nil {
+			p.logger.Debug("unable to close paused request handler", "error", err)
+		}
+		p.interceptClient = nil
+	}
+	if p.authClient != nil {
+		if err := p.authClient.Close(); err != nil {
+			p.logger.Debug("unable to close auth required handler", "error", err)
+		}
+		p.authClient = nil
+	}
+}
+
 // InterceptRequestCallback is a function type for request interception.
 // It allows users to define logic for handling intercepted requests.
 // The callback receives the current context and a RequestPausedReply,
@@ -84,15 +118,18 @@ func (p *page) RemoveInterceptRequest(_ context.Context, handle *InterceptReques
 // handleInterceptRequest manages the received paused requests,
 // invoking the respective callbacks for each paused request.
 func (p *page) handleInterceptRequest(ctx context.Context) error {
-	if err := p.EnableFetch(ctx); err != nil {
+	pc, err := p.client.Fetch.RequestPaused(ctx)
+	if err != nil {
 		return err
 	}
+	p.interceptClient = pc
 
-	pc, err := p.client.Fetch.RequestPaused(ctx)
+	ac, err := p.client.Fetch.AuthRequired(ctx)
 	if err != nil {
 		return err
 	}
-	p.interceptClient = pc
+	p.authClient = ac
+	go p.handleAuthRequired(ctx, ac)
 
 	go func() {
 		defer pc.Close()
@@ -0,0 +1,318 @@
+package gopilot
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/mafredri/cdp"
+	"github.com/mafredri/cdp/protocol/network"
+	cdppage "github.com/mafredri/cdp/protocol/page"
+)
+
+// WaitUntil is the point of the page load a navigation waits for.
+type WaitUntil string
+
+const (
+	// WaitNone returns as soon as the browser accepted the navigation.
+	WaitNone WaitUntil = ""
+
+	// WaitCommit waits until the new document is committed.
+	WaitCommit WaitUntil = "commit"
+
+	// WaitDOMContentLoaded waits for the DOMContentLoaded event.
+	WaitDOMContentLoaded WaitUntil = "DOMContentLoaded"
+
+	// WaitLoad waits for the load event.
+	WaitLoad WaitUntil = "load"
+
+	// WaitNetworkIdle0 waits for the load event and then for a quiet window
+	// without any request in flight.
+	WaitNetworkIdle0 WaitUntil = "networkIdle0"
+
+	// WaitNetworkIdle2 waits for the load event and then for a quiet window
+	// with at most two requests in flight, tolerating long-polling connections.
+	WaitNetworkIdle2 WaitUntil = "networkIdle2"
+
+	// WaitFirstMeaningfulPaint waits for the first meaningful paint.
+	WaitFirstMeaningfulPaint WaitUntil = "firstMeaningfulPaint"
+)
+
+// defaultQuietWindow is used when no quiet window is given for network idle.
+const defaultQuietWindow = 500 * time.Millisecond
+
+// lifecycle event names reported by Page.lifecycleEvent
+const (
+	lifecycleInit                 = "init"
+	lifecycleDOMContentLoaded     = "DOMContentLoaded"
+	lifecycleLoad                 = "load"
+	lifecycleFirstMeaningfulPaint = "firstMeaningfulPaint"
+)
+
+// navigationWaiter follows the lifecycle events of a frame, and the network
+// requests for the idle conditions, until a WaitUntil condition is met.
+// It subscribes before the navigation starts so no event is missed.
+type navigationWaiter struct {
+	until    WaitUntil
+	quiet    time.Duration
+	timeout  time.Duration
+	deadline time.Time
+	reached  map[string]bool // lifecycle events of the new document
+
+	lifecycle cdppage.LifecycleEventClient
+	sent      network.RequestWillBeSentClient
+	finished  network.LoadingFinishedClient
+	failed    network.LoadingFailedClient
+
+	// history navigations may not load a new document, see wait
+	withinDocument cdppage.NavigatedWithinDocumentClient
+	navigated      cdppage.FrameNavigatedClient
+}
+
+// newNavigationWaiter subscribes to the events needed for until.
+// The timeout, when positive, starts counting right away, see withDeadline.
+// A history waiter also completes on same-document and back-forward cache
+// navigations, which emit no lifecycle events.
+func (p *page) newNavigationWaiter(
+	ctx context.Context,
+	until WaitUntil,
+	quiet time.Duration,
+	timeout time.Duration,
+	history bool,
+) (*navigationWaiter, error) {
+	switch until {
+	case WaitNone, WaitCommit, WaitDOMContentLoaded, WaitLoad, WaitNetworkIdle0, WaitNetworkIdle2, WaitFirstMeaningfulPaint:
+	default:
+		return nil, fmt.Errorf("unknown wait condition %q", until)
+	}
+
+	if quiet <= 0 {
+		quiet = defaultQuietWindow
+	}
+	w := &navigationWaiter{until: until, quiet: quiet, timeout: timeout, reached: map[string]bool{}}
+	if timeout > 0 {
+		w.deadline = time.Now().Add(timeout)
+	}
+	if until == WaitNone {
+		return w, nil
+	}
+
+	var err error
+	if err = p.client.Page.SetLifecycleEventsEnabled(ctx, cdppage.NewSetLifecycleEventsEnabledArgs(true)); err != nil {
+		return nil, err
+	}
+	if w.lifecycle, err = p.client.Page.LifecycleEvent(ctx); err != nil {
+		return nil, err
+	}
+
+	if history {
+		if w.withinDocument, err = p.client.Page.NavigatedWithinDocument(ctx); err != nil {
+			w.close()
+			return nil, err
+		}
+		if w.navigated, err = p.client.Page.FrameNavigated(ctx); err != nil {
+			w.close()
+			return nil, err
+		}
+	}
+
+	if w.networkIdle() {
+		if err = p.client.Network.Enable(ctx, network.NewEnableArgs()); err != nil {
+			w.close()
+			return nil, err
+		}
+		if w.sent, err = p.client.Network.RequestWillBeSent(ctx); err != nil {
+			w.close()
+			return nil, err
+		}
+		if w.finished, err = p.client.Network.LoadingFinished(ctx); err != nil {
+			w.close()
+			return nil, err
+		}
+		if w.failed, err = p.client.Network.LoadingFailed(ctx); err != nil {
+			w.close()
+			return nil, err
+		}
+	}
+
+	// requests must be counted in the order they start and end
+	switch {
+	case history && w.networkIdle():
+		err = cdp.Sync(w.lifecycle, w.withinDocument, w.navigated, w.sent, w.finished, w.failed)
+	case history:
+		err = cdp.Sync(w.lifecycle, w.withinDocument, w.navigated)
+	case w.networkIdle():
+		err = cdp.Sync(w.lifecycle, w.sent, w.finished, w.failed)
+	}
+	if err != nil {
+		w.close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// networkIdle reports whether the condition needs the in flight requests.
+func (w *navigationWaiter) networkIdle() bool {
+	return w.until == WaitNetworkIdle0 || w.until == WaitNetworkIdle2
+}
+
+// close releases the event subscriptions.
+func (w *navigationWaiter) close() {
+	for _, c := range []interface{ Close() error }{w.lifecycle, w.sent, w.finished, w.failed, w.withinDocument, w.navigated} {
+		if c != nil {
+			_ = c.Close()
+		}
+	}
+}
+
+// withDeadline returns ctx bounded by the navigation timeout, if any.
+func (w *navigationWaiter) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if w.deadline.IsZero() {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, w.deadline)
+}
+
+// timeoutError turns err into a *NavigationTimeoutError when navCtx, made by
+// withDeadline, expired while ctx is still active.
+func (w *navigationWaiter) timeoutError(ctx context.Context, navCtx context.Context, err error) error {
+	if navCtx.Err() == nil || ctx.Err() != nil {
+		return err
+	}
+	return &NavigationTimeoutError{
+		WaitUntil: w.until,
+		Timeout:   w.timeout,
+		Reached:   slices.Sorted(maps.Keys(w.reached)),
+	}
+}
+
+// wait blocks until the condition is met for the document loaded by loaderID
+// in frameID. An empty loaderID adopts the first document committed in the
+// frame other than stale, the document shown before the navigation.
+func (w *navigationWaiter) wait(
+	ctx context.Context,
+	frameID cdppage.FrameID,
+	loaderID network.LoaderID,
+	stale network.LoaderID,
+) error {
+	if w.until == WaitNone {
+		return nil
+	}
+
+	reached := w.reached
+	inflight := map[network.RequestID]struct{}{}
+	maxInflight := 0
+	if w.until == WaitNetworkIdle2 {
+		maxInflight = 2
+	}
+
+	var idle <-chan time.Time
+
+	for {
+		if w.met(reached) {
+			return nil
+		}
+
+		// ready channels are replaced after each receive
+		var sent, finished, failed <-chan struct{}
+		if w.networkIdle() {
+			sent, finished, failed = w.sent.Ready(), w.finished.Ready(), w.failed.Ready()
+		}
+		var withinDocument, navigated <-chan struct{}
+		if w.withinDocument != nil {
+			withinDocument, navigated = w.withinDocument.Ready(), w.navigated.Ready()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-idle:
+			return nil
+
+		case <-w.lifecycle.Ready():
+			ev, err := w.lifecycle.Recv()
+			if err != nil {
+				return err
+			}
+			if ev.FrameID != frameID {
+				continue
+			}
+			if loaderID == "" && ev.Name == lifecycleInit && ev.LoaderID != stale {
+				loaderID = ev.LoaderID
+			}
+			if ev.LoaderID != loaderID {
+				continue
+			}
+			reached[ev.Name] = true
+
+		case <-withinDocument:
+			ev, err := w.withinDocument.Recv()
+			if err != nil {
+				return err
+			}
+			if ev.FrameID == frameID {
+				return nil
+			}
+
+		case <-navigated:
+			ev, err := w.navigated.Recv()
+			if err != nil {
+				return err
+			}
+			// a page restored from the back-forward cache is already loaded
+			if ev.Frame.ID == frameID && ev.Type == cdppage.NavigationTypeBackForwardCacheRestore {
+				return nil
+			}
+
+		case <-sent:
+			ev, err := w.sent.Recv()
+			if err != nil {
+				return err
+			}
+			inflight[ev.RequestID] = struct{}{}
+
+		case <-finished:
+			ev, err := w.finished.Recv()
+			if err != nil {
+				return err
+			}
+			delete(inflight, ev.RequestID)
+
+		case <-failed:
+			ev, err := w.failed.Recv()
+			if err != nil {
+				return err
+			}
+			delete(inflight, ev.RequestID)
+		}
+
+		// the quiet window restarts whenever the page gets busy again
+		if w.networkIdle() && reached[lifecycleLoad] {
+			if len(inflight) > maxInflight {
+				idle = nil
+			} else if idle == nil {
+				idle = time.After(w.quiet)
+			}
+		}
+	}
+}
+
+// met reports whether the lifecycle condition is met, network idle is
+// reported through the quiet window timer instead.
+func (w *navigationWaiter) met(reached map[string]bool) bool {
+	switch w.until {
+	case WaitCommit:
+		return reached[lifecycleInit]
+	case WaitDOMContentLoaded:
+		return reached[lifecycleDOMContentLoaded]
+	case WaitLoad:
+		return reached[lifecycleLoad]
+	case WaitFirstMeaningfulPaint:
+		return reached[lifecycleFirstMeaningfulPaint]
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package gopilot
 
 import (
 	"context"
+	"time"
 
 	"github.com/mafredri/cdp/protocol/network"
 	cdppage "github.com/mafredri/cdp/protocol/page"
@@ -9,85 +10,166 @@ import (
 
 // PageNavigateInput specifies the input for the Navigate method.
 // URL is the target URL to navigate to.
-// WaitDomContentLoad determines whether to wait for the DOM content to load.
+// WaitUntil determines which point of the page load to wait for.
 type PageNavigateInput struct {
-	URL                string // The URL to navigate to.
-	WaitDomContentLoad bool   // If true, waits for the DOM content to load before returning.
+	URL         string        // The URL to navigate to.
+	WaitUntil   WaitUntil     // The load condition to wait for, WaitNone returns right away.
+	QuietWindow time.Duration // How long the network must stay idle for WaitNetworkIdle0/2, 500ms by default.
+	Timeout     time.Duration // If set, a *NavigationTimeoutError is returned once exceeded.
 }
 
 // PageNavigateOutput represents the output of the Navigate method.
 // LoaderID is the ID associated with the loading process of the page.
+// The response fields describe the main document, they are empty for
+// documents loaded without a network request such as data URLs.
 type PageNavigateOutput struct {
-	LoaderID network.LoaderID // The LoaderID associated with the navigation.
+	LoaderID   network.LoaderID  // The LoaderID associated with the navigation.
+	FrameID    cdppage.FrameID   // The frame that navigated.
+	URL        string            // The final URL, after redirects.
+	Status     int               // The HTTP status code of the document response.
+	StatusText string            // The HTTP status text of the document response.
+	Headers    map[string]string // The headers of the document response.
+	Redirects  []PageRedirect    // The redirects followed, in order.
+
+	// Aborted reports that the browser aborted the navigation without
+	// committing a document, as when the URL starts a download. The response
+	// fields are empty then.
+	Aborted bool
 }
 
+// errorTextAborted is reported by Page.navigate when no document is
+// committed, e.g. for a download.
+const errorTextAborted = "net::ERR_ABORTED"
+
 // Navigate navigates the page to the specified URL.
-// Based on the input, it optionally waits for the DOM content to finish loading.
-// Returns a PageNavigateOutput containing the LoaderID or an error if navigation fails.
+// Based on the input, it waits for the page to reach the WaitUntil condition.
+// Returns a PageNavigateOutput describing the document response, a *NavigationError
+// when the browser could not load it, or another error if navigation fails.
+// HTTP error statuses are not errors, check PageNavigateOutput.Status, neither
+// are navigations turned into downloads, see PageNavigateOutput.Aborted.
 func (p *page) Navigate(ctx context.Context, in *PageNavigateInput) (*PageNavigateOutput, error) {
-	var domEvent cdppage.DOMContentEventFiredClient
-	var err error
+	// Subscribe before navigating to buffer the lifecycle and network events.
+	w, err := p.newNavigationWaiter(ctx, in.WaitUntil, in.QuietWindow, in.Timeout, false)
+	if err != nil {
+		return nil, err
+	}
+	defer w.close()
 
-	if in.WaitDomContentLoad {
-		// Open a DOMContentEventFired client to buffer this event.
-		domEvent, err = p.client.Page.DOMContentEventFired(ctx)
-		if err != nil {
-			return nil, err
-		}
-		defer domEvent.Close()
+	doc, err := p.newDocumentTracker(ctx)
+	if err != nil {
+		return nil, err
 	}
+	defer doc.close()
+
+	navCtx, cancel := w.withDeadline(ctx)
+	defer cancel()
 
 	p.logger.Debug("page navigation started", "url", in.URL)
 
-	rp, err := p.client.Page.Navigate(ctx, &cdppage.NavigateArgs{URL: in.URL})
+	rp, err := p.client.Page.Navigate(navCtx, &cdppage.NavigateArgs{URL: in.URL})
 	if err != nil {
-		return nil, err
+		return nil, w.timeoutError(ctx, navCtx, err)
 	}
-
-	if in.WaitDomContentLoad && domEvent != nil {
-		p.logger.Debug("page waiting for DOM content to load")
-		// Wait for the DOMContentEventFired
-		if _, err = domEvent.Recv(); err != nil {
-			return nil, err
+	if rp.ErrorText != nil {
+		if *rp.ErrorText == errorTextAborted {
+			aborted, err := p.abortedWithoutDocument(navCtx, rp)
+			if err != nil {
+				return nil, w.timeoutError(ctx, navCtx, err)
+			}
+			if aborted {
+				p.logger.Debug("page navigation aborted without a document", "url", in.URL)
+				return &PageNavigateOutput{FrameID: rp.FrameID, URL: in.URL, Aborted: true}, nil
+			}
 		}
+		return nil, newNavigationError(in.URL, *rp.ErrorText)
 	}
 
-	p.logger.Debug("page navigation finished", "frame", rp.FrameID)
-
 	var loaderId network.LoaderID
 	if rp.LoaderID != nil {
 		loaderId = *rp.LoaderID
 	}
 
-	return &PageNavigateOutput{LoaderID: loaderId}, nil
+	// same-document navigations do not load a new document
+	if loaderId != "" {
+		p.logger.Debug("page waiting for load condition", "wait_until", in.WaitUntil)
+		if err = w.wait(navCtx, rp.FrameID, loaderId, ""); err != nil {
+			return nil, w.timeoutError(ctx, navCtx, err)
+		}
+	}
+
+	out := &PageNavigateOutput{
+		LoaderID: loaderId,
+		FrameID:  rp.FrameID,
+		URL:      in.URL,
+	}
+	if loaderId != "" {
+		if err = doc.collect(loaderId, out); err != nil {
+			return nil, err
+		}
+	}
+
+	p.logger.Debug("page navigation finished", "frame", rp.FrameID, "url", out.URL, "status", out.Status)
+
+	return out, nil
+}
+
+// abortedWithoutDocument reports whether the aborted navigation left the
+// frame on its previous document, which is the case for downloads.
+func (p *page) abortedWithoutDocument(ctx context.Context, rp *cdppage.NavigateReply) (bool, error) {
+	if rp.LoaderID == nil {
+		return true, nil
+	}
+
+	tree, err := p.client.Page.GetFrameTree(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	frames := []cdppage.FrameTree{tree.FrameTree}
+	for len(frames) > 0 {
+		f := frames[0]
+		frames = append(frames[1:], f.ChildFrames...)
+		if f.Frame.ID == rp.FrameID {
+			return f.Frame.LoaderID != *rp.LoaderID, nil
+		}
+	}
+
+	return true, nil
 }
 
 // PageReloadInput specifies the input for the Reload method.
 // LoaderID is the ID associated with the previous loading process.
-// WaitDomContentLoad determines whether to wait for the DOM content to load after reloading.
+// WaitUntil determines which point of the page load to wait for after reloading.
 type PageReloadInput struct {
-	LoaderID           network.LoaderID // The LoaderID of the previous load.
-	WaitDomContentLoad bool             // If true, waits for the DOM content to load after reload.
+	LoaderID    network.LoaderID // The LoaderID of the previous load.
+	WaitUntil   WaitUntil        // The load condition to wait for, WaitNone returns right away.
+	QuietWindow time.Duration    // How long the network must stay idle for WaitNetworkIdle0/2, 500ms by default.
+	Timeout     time.Duration    // If set, a *NavigationTimeoutError is returned once exceeded.
 }
 
 // PageReloadOutput represents the output of the Reload method.
 type PageReloadOutput struct{}
 
 // Reload reloads the current page.
-// It optionally waits for the DOM content to finish loading before returning.
+// It waits for the reloaded page to reach the WaitUntil condition before returning.
 // Returns a PageReloadOutput or an error if reload fails.
 func (p *page) Reload(ctx context.Context, in *PageReloadInput) (*PageReloadOutput, error) {
-	var domEvent cdppage.DOMContentEventFiredClient
-	var err error
+	w, err := p.newNavigationWaiter(ctx, in.WaitUntil, in.QuietWindow, in.Timeout, false)
+	if err != nil {
+		return nil, err
+	}
+	defer w.close()
 
-	if in.WaitDomContentLoad {
-		// Open a DOMContentEventFired client to buffer this event.
-		domEvent, err = p.client.Page.DOMContentEventFired(ctx)
-		if err != nil {
-			return nil, err
-		}
-		defer domEvent.Close()
+	navCtx, cancel := w.withDeadline(ctx)
+	defer cancel()
+
+	// the reload reply carries no loader, the new document is told apart
+	// from the current one of the main frame
+	tree, err := p.client.Page.GetFrameTree(navCtx)
+	if err != nil {
+		return nil, w.timeoutError(ctx, navCtx, err)
 	}
+	frame := tree.FrameTree.Frame
 
 	logger := p.logger
 	if in.LoaderID != "" {
@@ -101,16 +183,13 @@ func (p *page) Reload(ctx context.Context, in *PageReloadInput) (*PageReloadOutp
 		args.LoaderID = &in.LoaderID
 	}
 
-	if err = p.client.Page.Reload(ctx, args); err != nil {
-		return nil, err
+	if err = p.client.Page.Reload(navCtx, args); err != nil {
+		return nil, w.timeoutError(ctx, navCtx, err)
 	}
 
-	if in.WaitDomContentLoad && domEvent != nil {
-		p.logger.Debug("page waiting for DOM content to load")
-		_, err = domEvent.Recv() // Wait for the DOMContentEventFired
-		if err != nil {
-			return nil, err
-		}
+	logger.Debug("page waiting for load condition", "wait_until", in.WaitUntil)
+	if err = w.wait(navCtx, frame.ID, "", frame.LoaderID); err != nil {
+		return nil, w.timeoutError(ctx, navCtx, err)
 	}
 
 	return &PageReloadOutput{}, nil
@@ -0,0 +1,99 @@
+package gopilot
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mafredri/cdp"
+	"github.com/mafredri/cdp/protocol/network"
+)
+
+// PageRedirect is a redirect followed while loading the main document.
+type PageRedirect struct {
+	URL      string // URL is the request URL that was redirected.
+	Status   int    // Status is the redirect status code, e.g. 301.
+	Location string // Location is the URL redirected to.
+}
+
+// documentTracker follows the network events of the main document request,
+// recording its redirects and final response.
+type documentTracker struct {
+	sent     network.RequestWillBeSentClient
+	received network.ResponseReceivedClient
+}
+
+// newDocumentTracker subscribes to the request events, it must be created
+// before the navigation starts.
+func (p *page) newDocumentTracker(ctx context.Context) (*documentTracker, error) {
+	if err := p.client.Network.Enable(ctx, network.NewEnableArgs()); err != nil {
+		return nil, err
+	}
+
+	sent, err := p.client.Network.RequestWillBeSent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	received, err := p.client.Network.ResponseReceived(ctx)
+	if err != nil {
+		_ = sent.Close()
+		return nil, err
+	}
+
+	// redirects must be seen before the final response
+	if err = cdp.Sync(sent, received); err != nil {
+		_ = sent.Close()
+		_ = received.Close()
+		return nil, err
+	}
+
+	return &documentTracker{sent: sent, received: received}, nil
+}
+
+func (t *documentTracker) close() {
+	_ = t.sent.Close()
+	_ = t.received.Close()
+}
+
+// collect consumes the buffered events and fills out with the redirects and
+// the response of the document loaded by loaderID. The browser reports the
+// response before replying to Page.navigate, so nothing is waited for.
+func (t *documentTracker) collect(loaderID network.LoaderID, out *PageNavigateOutput) error {
+	for {
+		select {
+		case <-t.sent.Ready():
+			ev, err := t.sent.Recv()
+			if err != nil {
+				return err
+			}
+			if ev.LoaderID != loaderID || ev.Type != network.ResourceTypeDocument || ev.RedirectResponse == nil {
+				continue
+			}
+			out.Redirects = append(out.Redirects, PageRedirect{
+				URL:      ev.RedirectResponse.URL,
+				Status:   ev.RedirectResponse.Status,
+				Location: ev.Request.URL,
+			})
+
+		case <-t.received.Ready():
+			ev, err := t.received.Recv()
+			if err != nil {
+				return err
+			}
+			if ev.LoaderID != loaderID || ev.Type != network.ResourceTypeDocument {
+				continue
+			}
+			out.URL = ev.Response.URL
+			out.Status = ev.Response.Status
+			out.StatusText = ev.Response.StatusText
+			out.Headers = map[string]string{}
+			if len(ev.Response.Headers) > 0 {
+				if err = json.Unmarshal(ev.Response.Headers, &out.Headers); err != nil {
+					return err
+				}
+			}
+
+		default:
+			return nil
+		}
+	}
+}
@@ -39,8 +39,13 @@ func (p *page) GetCookies(ctx context.Context, in *GetCookiesInput) (*GetCookies
 		return nil, err
 	}
 
+	return &GetCookiesOutput{Cookies: toPageCookies(rp.Cookies)}, nil
+}
+
+// toPageCookies converts protocol cookies into PageCookie values.
+func toPageCookies(in []network.Cookie) []PageCookie {
 	var cookies []PageCookie
-	for _, c := range rp.Cookies {
+	for _, c := range in {
 		pc := PageCookie{
 			Name:     c.Name,
 			Value:    c.Value,
@@ -59,7 +64,7 @@ func (p *page) GetCookies(ctx context.Context, in *GetCookiesInput) (*GetCookies
 		cookies = append(cookies, pc)
 	}
 
-	return &GetCookiesOutput{Cookies: cookies}, nil
+	return cookies
 }
 
 // SetCookiesInput specifies the input for the SetCookies method.
@@ -74,8 +79,17 @@ type SetCookiesOutput struct{}
 // SetCookies sets the specified cookies in the browser.
 // Returns a SetCookiesOutput or an error if setting fails.
 func (p *page) SetCookies(ctx context.Context, in *SetCookiesInput) (*SetCookiesOutput, error) {
+	err := p.client.Storage.SetCookies(ctx, &storage.SetCookiesArgs{Cookies: toCookieParams(in.Cookies)})
+	if err != nil {
+		return nil, err
+	}
+	return &SetCookiesOutput{}, nil
+}
+
+// toCookieParams converts PageCookie values into protocol cookie parameters.
+func toCookieParams(in []PageCookie) []network.CookieParam {
 	var cookies []network.CookieParam
-	for _, c := range in.Cookies {
+	for _, c := range in {
 		ncp := network.CookieParam{
 			Name:  c.Name,
 			Value: c.Value,
@@ -98,11 +112,7 @@ func (p *page) SetCookies(ctx context.Context, in *SetCookiesInput) (*SetCookies
 		cookies = append(cookies, ncp)
 	}
 
-	err := p.client.Storage.SetCookies(ctx, &storage.SetCookiesArgs{Cookies: cookies})
-	if err != nil {
-		return nil, err
-	}
-	return &SetCookiesOutput{}, nil
+	return cookies
 }
 
 // ClearCookiesInput specifies the input for the ClearCookies method.
@@ -0,0 +1,557 @@
+package gopilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mafredri/cdp"
+	"github.com/mafredri/cdp/protocol/dom"
+	"github.com/mafredri/cdp/protocol/runtime"
+)
+
+// SelectorType tells how a selector is matched against the document.
+type SelectorType string
+
+const (
+	// SelectorCSS matches a CSS selector, it is the default.
+	SelectorCSS SelectorType = "css"
+
+	// SelectorXPath matches the first node of an XPath expression.
+	SelectorXPath SelectorType = "xpath"
+
+	// SelectorText matches the innermost element whose text contains the selector.
+	SelectorText SelectorType = "text"
+)
+
+// ElementState is the state of an element a wait is satisfied by.
+type ElementState string
+
+const (
+	// ElementAttached waits for the element to be in the DOM, it is the default.
+	ElementAttached ElementState = "attached"
+
+	// ElementDetached waits for no element to match the selector.
+	ElementDetached ElementState = "detached"
+
+	// ElementVisible waits for the element to be in the DOM with a non-empty
+	// box and without visibility:hidden.
+	ElementVisible ElementState = "visible"
+
+	// ElementHidden waits for the element to be either detached or not visible.
+	ElementHidden ElementState = "hidden"
+)
+
+// PageWaitForSelectorInput specifies the input for the WaitForSelector method.
+type PageWaitForSelectorInput struct {
+	Selector string
+	Type     SelectorType // How the selector is matched, SelectorCSS by default.
+	State    ElementState // The state to wait for, ElementAttached by default.
+}
+
+// PageWaitForSelectorOutput represents the output of the WaitForSelector method.
+type PageWaitForSelectorOutput struct {
+	// Element is the matched element, nil when waiting for ElementDetached or ElementHidden.
+	Element Element
+}
+
+// waitForSelectorFunction resolves once the selector reaches the state within
+// this, re-checking on DOM mutations and on the events that may change the
+// visibility of an element. The timeout only releases the observer, the caller
+// gives up through its own context and stops the wait by key, see stopWait.
+const waitForSelectorFunction = `function(selector, type, state, timeout, key) {
+	const root = this;
+	const doc = root.ownerDocument || root;
+
+	const find = () => {
+		if (type === 'xpath') {
+			return doc.evaluate(selector, root, null, XPathResult.FIRST_ORDERED_NODE_TYPE, null).singleNodeValue;
+		}
+		if (type === 'text') {
+			const skip = new Set(['SCRIPT', 'STYLE', 'NOSCRIPT', 'TEMPLATE']);
+			const contains = (el) => !skip.has(el.tagName) && (el.textContent || '').includes(selector);
+			const walker = doc.createTreeWalker(root, NodeFilter.SHOW_ELEMENT, {
+				acceptNode: (el) => contains(el) ? NodeFilter.FILTER_ACCEPT : NodeFilter.FILTER_REJECT,
+			});
+			for (let el = walker.nextNode(); el; el = walker.nextNode()) {
+				if (![...el.children].some(contains)) {
+					return el;
+				}
+			}
+			return null;
+		}
+		return root.querySelector(selector);
+	};
+
+	const visible = (el) => {
+		if (el && el.nodeType !== Node.ELEMENT_NODE) {
+			el = el.parentElement;
+		}
+		if (!el || !el.isConnected) {
+			return false;
+		}
+		if (getComputedStyle(el).visibility === 'hidden') {
+			return false;
+		}
+		const rect = el.getBoundingClientRect();
+		return rect.width > 0 && rect.height > 0;
+	};
+
+	const check = () => {
+		const el = find();
+		switch (state) {
+		case 'detached':
+			return el ? undefined : null;
+		case 'visible':
+			return visible(el) ? el : undefined;
+		case 'hidden':
+			return visible(el) ? undefined : null;
+		}
+		return el || undefined;
+	};
+
+	const found = check();
+	if (found !== undefined) {
+		return found;
+	}
+
+	return new Promise((resolve, reject) => {
+		const events = ['transitionend', 'animationend', 'load', 'resize'];
+		const waits = globalThis[Symbol.for('gopilot.waits')] ||= new Map();
+		let timer;
+
+		const done = () => {
+			observer.disconnect();
+			events.forEach((name) => doc.defaultView.removeEventListener(name, onChange, true));
+			clearTimeout(timer);
+			waits.delete(key);
+		};
+		const onChange = () => {
+			const result = check();
+			if (result !== undefined) {
+				done();
+				resolve(result);
+			}
+		};
+
+		const observer = new MutationObserver(onChange);
+		observer.observe(doc, {childList: true, subtree: true, attributes: true, characterData: true});
+		events.forEach((name) => doc.defaultView.addEventListener(name, onChange, true));
+
+		waits.set(key, () => {
+			done();
+			reject(new Error('wait for selector stopped'));
+		});
+		if (timeout > 0) {
+			timer = setTimeout(() => {
+				done();
+				reject(new Error('wait for selector timed out'));
+			}, timeout);
+		}
+	});
+}`
+
+// stopWaitFunction calls the stop function of the pending wait with the given
+// key, registered by the wait functions in the realm of the page.
+const stopWaitFunction = `function(key) {
+	const waits = globalThis[Symbol.for('gopilot.waits')];
+	const stop = waits && waits.get(key);
+	if (stop) {
+		stop();
+	}
+}`
+
+// waitStopTimeout bounds the calls releasing the page side of a wait.
+const waitStopTimeout = 5 * time.Second
+
+var waitKeyID atomic.Uint64
+
+// newWaitKey returns the key a wait registers its stop function with.
+func newWaitKey() string {
+	return fmt.Sprintf("wait-%d", waitKeyID.Add(1))
+}
+
+// stopWait stops the page side of a wait the caller gave up on, so its
+// observers and timers do not outlive it. It runs in the realm of objectID,
+// or in the page main world when nil. Failures are ignored, the document
+// may be gone already.
+func stopWait(client *cdp.Client, objectID *runtime.RemoteObjectID, key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), waitStopTimeout)
+	defer cancel()
+
+	if objectID == nil {
+		expression := fmt.Sprintf("(%s)(%q)", stopWaitFunction, key)
+		_, _ = client.Runtime.Evaluate(ctx, runtime.NewEvaluateArgs(expression))
+		return
+	}
+
+	args, err := callArguments(key)
+	if err != nil {
+		return
+	}
+	_, _ = client.Runtime.CallFunctionOn(ctx, &runtime.CallFunctionOnArgs{
+		FunctionDeclaration: stopWaitFunction,
+		ObjectID:            objectID,
+		Arguments:           args,
+	})
+}
+
+// releaseObject releases a remote object that is no longer needed.
+func releaseObject(client *cdp.Client, objectID *runtime.RemoteObjectID) {
+	if objectID == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), waitStopTimeout)
+	defer cancel()
+
+	_ = client.Runtime.ReleaseObject(ctx, runtime.NewReleaseObjectArgs(*objectID))
+}
+
+// waitReleaseDelay keeps the page side of a wait alive a bit longer than the
+// context deadline, so the context error is the one reported.
+const waitReleaseDelay = time.Second
+
+// WaitForSelector waits until an element matching the selector reaches the
+// given state and returns it. The wait is bounded by ctx only, it survives
+// navigations by starting over in the new document.
+func (p *page) WaitForSelector(ctx context.Context, in *PageWaitForSelectorInput) (*PageWaitForSelectorOutput, error) {
+	if err := validateSelectorWait(in.Type, in.State); err != nil {
+		return nil, err
+	}
+
+	p.logger.Debug("waiting for selector", "selector", in.Selector, "type", in.Type, "state", in.State)
+
+	var el Element
+	err := p.retryNavigations(ctx, func() error {
+		doc, err := evaluateObject(ctx, p.client, "document")
+		if err != nil {
+			return err
+		}
+		defer releaseObject(p.client, doc.ObjectID)
+
+		el, err = waitForSelector(ctx, p.client, *doc.ObjectID, in.Selector, in.Type, in.State)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PageWaitForSelectorOutput{Element: el}, nil
+}
+
+// retryNavigations runs fn again whenever it fails because the document was
+// replaced by a navigation, until it succeeds, fails otherwise or ctx is done.
+func (p *page) retryNavigations(ctx context.Context, fn func() error) error {
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !isContextLost(err) {
+			return err
+		}
+
+		// the new document may not be ready for scripts yet
+		p.logger.Debug("document replaced while waiting, retrying", "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// evaluateObject evaluates expression and returns a reference to its result.
+func evaluateObject(ctx context.Context, client *cdp.Client, expression string) (*runtime.RemoteObject, error) {
+	rp, err := client.Runtime.Evaluate(ctx, runtime.NewEvaluateArgs(expression))
+	if err != nil {
+		return nil, err
+	}
+	if rp.ExceptionDetails != nil {
+		return nil, exceptionError(rp.ExceptionDetails)
+	}
+	return &rp.Result, nil
+}
+
+// validateSelectorWait rejects unknown selector types and states.
+func validateSelectorWait(typ SelectorType, state ElementState) error {
+	switch typ {
+	case "", SelectorCSS, SelectorXPath, SelectorText:
+	default:
+		return fmt.Errorf("unknown selector type %q", typ)
+	}
+
+	switch state {
+	case "", ElementAttached, ElementDetached, ElementVisible, ElementHidden:
+	default:
+		return fmt.Errorf("unknown element state %q", state)
+	}
+
+	return nil
+}
+
+// waitForSelector runs the wait within the node of objectID and describes
+// the element it resolves with, if any.
+func waitForSelector(
+	ctx context.Context,
+	client *cdp.Client,
+	objectID runtime.RemoteObjectID,
+	selector string,
+	typ SelectorType,
+	state ElementState,
+) (Element, error) {
+	var timeout time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline) + waitReleaseDelay
+	}
+
+	key := newWaitKey()
+	args, err := callArguments(selector, typ, state, timeout.Milliseconds(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	awaitPromise := true
+	rp, err := client.Runtime.CallFunctionOn(ctx, &runtime.CallFunctionOnArgs{
+		FunctionDeclaration: waitForSelectorFunction,
+		ObjectID:            &objectID,
+		Arguments:           args,
+		AwaitPromise:        &awaitPromise,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			stopWait(client, &objectID, key)
+		}
+		return nil, err
+	}
+	if rp.ExceptionDetails != nil {
+		return nil, exceptionError(rp.ExceptionDetails)
+	}
+
+	if rp.Result.ObjectID == nil {
+		return nil, nil
+	}
+
+	drp, err := client.DOM.DescribeNode(ctx, &dom.DescribeNodeArgs{
+		ObjectID: rp.Result.ObjectID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newElement(drp.Node, rp.Result, client), nil
+}
+
+// callArguments encodes values as arguments of Runtime.callFunctionOn.
+func callArguments(values ...any) ([]runtime.CallArgument, error) {
+	args := make([]runtime.CallArgument, 0, len(values))
+	for _, v := range values {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, runtime.CallArgument{Value: raw})
+	}
+	return args, nil
+}
+
+// exceptionError turns an exception thrown by a script into an error.
+func exceptionError(d *runtime.ExceptionDetails) error {
+	if d.Exception != nil && d.Exception.Description != nil {
+		return errors.New(*d.Exception.Description)
+	}
+	return errors.New(d.Text)
+}
+
+// isContextLost reports whether err comes from the execution context of the
+// document being destroyed, as happens on navigation.
+func isContextLost(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "Execution context was destroyed") ||
+		strings.Contains(msg, "Cannot find context with specified id") ||
+		strings.Contains(msg, "Cannot find object with given id")
+}
+
+// PollingMode is how often the predicate of WaitForFunction is checked.
+type PollingMode string
+
+const (
+	// PollAnimationFrame checks the predicate on every animation frame, it is the default.
+	PollAnimationFrame PollingMode = "raf"
+
+	// PollInterval checks the predicate every Interval.
+	PollInterval PollingMode = "interval"
+
+	// PollMutation checks the predicate whenever the DOM changes.
+	PollMutation PollingMode = "mutation"
+)
+
+// defaultPollInterval is used by PollInterval when no interval is given.
+const defaultPollInterval = 100 * time.Millisecond
+
+// PageWaitForFunctionInput specifies the input for the WaitForFunction method.
+type PageWaitForFunctionInput struct {
+	// Function is either a JavaScript function, called with Args, e.g.
+	// "(n) => document.querySelectorAll('.spinner').length <= n",
+	// or an expression evaluated on each check, e.g. "window.appReady === true".
+	// It may return a promise.
+	Function string
+	Args     []any         // Args are passed to Function, they must be JSON serializable.
+	Polling  PollingMode   // When the predicate is checked, PollAnimationFrame by default.
+	Interval time.Duration // The interval of PollInterval, 100ms by default.
+}
+
+// PageWaitForFunctionOutput represents the output of the WaitForFunction method.
+type PageWaitForFunctionOutput struct {
+	Value json.RawMessage // Value is the truthy value returned by the predicate.
+}
+
+// waitForFunctionExpression resolves with the first truthy value of the
+// predicate, checked as the polling mode says. As for the selector wait the
+// timeout only releases the page side of the wait, which is stopped by key
+// when the caller gives up.
+const waitForFunctionExpression = `(async (predicate, args, polling, interval, timeout, key) => {
+	const value = await predicate(...args);
+	if (value) {
+		return value;
+	}
+
+	return new Promise((resolve, reject) => {
+		const waits = globalThis[Symbol.for('gopilot.waits')] ||= new Map();
+		let stopped = false;
+		let cleanup = () => {};
+		let timer;
+
+		const stop = () => {
+			stopped = true;
+			cleanup();
+			clearTimeout(timer);
+			waits.delete(key);
+		};
+		const check = async () => {
+			if (stopped) {
+				return;
+			}
+			try {
+				const value = await predicate(...args);
+				if (value && !stopped) {
+					stop();
+					resolve(value);
+				}
+				return !!value;
+			} catch (err) {
+				stop();
+				reject(err);
+				return true;
+			}
+		};
+
+		if (polling === 'mutation') {
+			const observer = new MutationObserver(() => check());
+			observer.observe(document, {childList: true, subtree: true, attributes: true, characterData: true});
+			cleanup = () => observer.disconnect();
+		} else if (polling === 'interval') {
+			const next = async () => {
+				if (!(await check()) && !stopped) {
+					handle = setTimeout(next, interval);
+				}
+			};
+			let handle = setTimeout(next, interval);
+			cleanup = () => clearTimeout(handle);
+		} else {
+			const next = async () => {
+				if (!(await check()) && !stopped) {
+					handle = requestAnimationFrame(next);
+				}
+			};
+			let handle = requestAnimationFrame(next);
+			cleanup = () => cancelAnimationFrame(handle);
+		}
+
+		waits.set(key, () => {
+			stop();
+			reject(new Error('wait for function stopped'));
+		});
+		if (timeout > 0) {
+			timer = setTimeout(() => {
+				stop();
+				reject(new Error('wait for function timed out'));
+			}, timeout);
+		}
+	});
+})`
+
+// WaitForFunction waits until the predicate returns a truthy value and
+// returns it. The wait is bounded by ctx only, it survives navigations by
+// starting over in the new document. An exception thrown by the predicate
+// ends the wait with an error.
+func (p *page) WaitForFunction(ctx context.Context, in *PageWaitForFunctionInput) (*PageWaitForFunctionOutput, error) {
+	polling := in.Polling
+	switch polling {
+	case "":
+		polling = PollAnimationFrame
+	case PollAnimationFrame, PollInterval, PollMutation:
+	default:
+		return nil, fmt.Errorf("unknown polling mode %q", polling)
+	}
+
+	interval := in.Interval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	args := in.Args
+	if args == nil {
+		args = []any{}
+	}
+	rawArgs, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode arguments: %w", err)
+	}
+
+	p.logger.Debug("waiting for function", "polling", polling)
+
+	var res *runtime.EvaluateReply
+	err = p.retryNavigations(ctx, func() error {
+		var timeout time.Duration
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = time.Until(deadline) + waitReleaseDelay
+		}
+
+		// the source is evaluated on every check, so expressions are
+		// re-evaluated and functions are called with the arguments
+		key := newWaitKey()
+		expression := fmt.Sprintf("%s((...args) => { const f = (%s\n); return typeof f === 'function' ? f(...args) : f; }, %s, %q, %d, %d, %q)",
+			waitForFunctionExpression, in.Function, rawArgs, polling, interval.Milliseconds(), timeout.Milliseconds(), key)
+
+		var err error
+		res, err = evaluateReply(ctx, p.client, &PageEvaluateInput{
+			Expression:   expression,
+			AwaitPromise: true,
+			ReturnValue:  true,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				stopWait(p.client, nil, key)
+			}
+			return err
+		}
+		if res.ExceptionDetails != nil {
+			return exceptionError(res.ExceptionDetails)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PageWaitForFunctionOutput{Value: res.Result.Value}, nil
+}
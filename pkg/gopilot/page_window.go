@@ -0,0 +1,158 @@
+package gopilot
+
+import (
+	"context"
+
+	"github.com/mafredri/cdp"
+	cdpbrowser "github.com/mafredri/cdp/protocol/browser"
+)
+
+// WindowState is the state of a browser window.
+type WindowState string
+
+const (
+	WindowNormal     WindowState = "normal"
+	WindowMinimized  WindowState = "minimized"
+	WindowMaximized  WindowState = "maximized"
+	WindowFullscreen WindowState = "fullscreen"
+)
+
+// WindowBounds holds the position, size and state of a browser window.
+// When the window is minimized the restored position and size are reported.
+type WindowBounds struct {
+	WindowID int         // WindowID identifies the window, it is ignored when setting bounds.
+	Left     int         // Left is the offset from the left edge of the screen in pixels.
+	Top      int         // Top is the offset from the top edge of the screen in pixels.
+	Width    int         // Width is the window width in pixels.
+	Height   int         // Height is the window height in pixels.
+	State    WindowState // State is ignored when setting bounds, see SetWindowState.
+}
+
+// GetWindowBounds returns the bounds of the window holding the page.
+func (p *page) GetWindowBounds(ctx context.Context) (*WindowBounds, error) {
+	rp, err := p.client.Browser.GetWindowForTarget(ctx, cdpbrowser.NewGetWindowForTargetArgs())
+	if err != nil {
+		return nil, err
+	}
+
+	return toWindowBounds(rp.WindowID, rp.Bounds), nil
+}
+
+// SetWindowBounds moves and resizes the window holding the page.
+func (p *page) SetWindowBounds(ctx context.Context, in *WindowBounds) error {
+	rp, err := p.client.Browser.GetWindowForTarget(ctx, cdpbrowser.NewGetWindowForTargetArgs())
+	if err != nil {
+		return err
+	}
+
+	return setWindowBounds(ctx, p.client, rp.WindowID, in)
+}
+
+// SetWindowState maximizes, minimizes, restores or makes fullscreen the window holding the page.
+func (p *page) SetWindowState(ctx context.Context, state WindowState) error {
+	rp, err := p.client.Browser.GetWindowForTarget(ctx, cdpbrowser.NewGetWindowForTargetArgs())
+	if err != nil {
+		return err
+	}
+
+	return setWindowState(ctx, p.client, rp.WindowID, state)
+}
+
+// GetWindowBounds returns the bounds of the window with the given id.
+func (b *browser) GetWindowBounds(ctx context.Context, windowID int) (*WindowBounds, error) {
+	client, err := b.browserClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id := cdpbrowser.WindowID(windowID)
+	rp, err := client.Browser.GetWindowBounds(ctx, cdpbrowser.NewGetWindowBoundsArgs(id))
+	if err != nil {
+		return nil, err
+	}
+
+	return toWindowBounds(id, rp.Bounds), nil
+}
+
+// SetWindowBounds moves and resizes the window with the given id.
+func (b *browser) SetWindowBounds(ctx context.Context, windowID int, in *WindowBounds) error {
+	client, err := b.browserClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	return setWindowBounds(ctx, client, cdpbrowser.WindowID(windowID), in)
+}
+
+// SetWindowState changes the state of the window with the given id.
+func (b *browser) SetWindowState(ctx context.Context, windowID int, state WindowState) error {
+	client, err := b.browserClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	return setWindowState(ctx, client, cdpbrowser.WindowID(windowID), state)
+}
+
+// setWindowBounds applies the position and size of in. The browser rejects
+// bounds for windows that are not in the normal state, so they are restored first.
+func setWindowBounds(ctx context.Context, client *cdp.Client, id cdpbrowser.WindowID, in *WindowBounds) error {
+	if err := setWindowState(ctx, client, id, WindowNormal); err != nil {
+		return err
+	}
+
+	bounds := cdpbrowser.Bounds{
+		Left:   &in.Left,
+		Top:    &in.Top,
+		Width:  &in.Width,
+		Height: &in.Height,
+	}
+
+	return client.Browser.SetWindowBounds(ctx, cdpbrowser.NewSetWindowBoundsArgs(id, bounds))
+}
+
+// setWindowState changes the window state, position and size are kept by the browser.
+// Switching between two non-normal states goes through the normal state, as the
+// browser does not allow e.g. minimizing a fullscreen window directly.
+func setWindowState(ctx context.Context, client *cdp.Client, id cdpbrowser.WindowID, state WindowState) error {
+	rp, err := client.Browser.GetWindowBounds(ctx, cdpbrowser.NewGetWindowBoundsArgs(id))
+	if err != nil {
+		return err
+	}
+
+	current := WindowState(rp.Bounds.WindowState)
+	if current == state {
+		return nil
+	}
+
+	if current != WindowNormal && state != WindowNormal {
+		normal := cdpbrowser.Bounds{WindowState: cdpbrowser.WindowStateNormal}
+		if err = client.Browser.SetWindowBounds(ctx, cdpbrowser.NewSetWindowBoundsArgs(id, normal)); err != nil {
+			return err
+		}
+	}
+
+	bounds := cdpbrowser.Bounds{WindowState: cdpbrowser.WindowState(state)}
+
+	return client.Browser.SetWindowBounds(ctx, cdpbrowser.NewSetWindowBoundsArgs(id, bounds))
+}
+
+func toWindowBounds(id cdpbrowser.WindowID, b cdpbrowser.Bounds) *WindowBounds {
+	out := &WindowBounds{
+		WindowID: int(id),
+		State:    WindowState(b.WindowState),
+	}
+	if b.Left != nil {
+		out.Left = *b.Left
+	}
+	if b.Top != nil {
+		out.Top = *b.Top
+	}
+	if b.Width != nil {
+		out.Width = *b.Width
+	}
+	if b.Height != nil {
+		out.Height = *b.Height
+	}
+	return out
+}
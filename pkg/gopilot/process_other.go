@@ -0,0 +1,31 @@
+//go:build !unix
+
+package gopilot
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on platforms without process groups.
+func setProcessGroup(_ *exec.Cmd) {}
+
+// terminateProcess stops the browser process, there is no graceful signal
+// available on these platforms so it is killed right away.
+func terminateProcess(cmd *exec.Cmd) error {
+	return killProcess(cmd)
+}
+
+// killProcess forcefully stops the browser process.
+func killProcess(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+
+	err := cmd.Process.Kill()
+	if errors.Is(err, os.ErrProcessDone) {
+		return nil
+	}
+	return err
+}
@@ -2,6 +2,7 @@ package gopilot
 
 import (
 	"context"
+	"sync"
 	"time"
 )
 
@@ -13,3 +14,36 @@ func sleepWithCtx(ctx context.Context, d time.Duration) error {
 		return nil
 	}
 }
+
+// ring keeps the last values added to it, discarding the oldest ones.
+type ring[T any] struct {
+	mux    sync.Mutex
+	size   int
+	values []T
+}
+
+func newRing[T any](size int) *ring[T] {
+	return &ring[T]{size: size, values: make([]T, 0, size)}
+}
+
+// Add appends a value, dropping the oldest one when the ring is full.
+func (r *ring[T]) Add(v T) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if len(r.values) == r.size {
+		copy(r.values, r.values[1:])
+		r.values = r.values[:r.size-1]
+	}
+	r.values = append(r.values, v)
+}
+
+// Values returns a copy of the stored values, oldest first.
+func (r *ring[T]) Values() []T {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	values := make([]T, len(r.values))
+	copy(values, r.values)
+	return values
+}